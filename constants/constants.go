@@ -21,5 +21,6 @@ const (
 	Comma              = ","
 	LeftBracket        = "("
 	RightBracket       = ")"
+	Dot                = "."
 	DefaultPrimaryName = "id"
 )
@@ -18,26 +18,54 @@
 package constants
 
 const (
-	And       = "AND"
-	Or        = "OR"
-	In        = "IN"
-	Not       = "NOT"
-	Like      = "LIKE"
-	Eq        = "="
-	Ne        = "<>"
-	Gt        = ">"
-	Ge        = ">="
-	Lt        = "<"
-	Le        = "<="
-	IsNull    = "IS NULL"
-	IsNotNull = "IS NOT NULL"
-	Between   = "BETWEEN"
-	Desc      = "DESC"
-	Asc       = "ASC"
-	As        = "AS"
-	SUM       = "SUM"
-	AVG       = "AVG"
-	MAX       = "MAX"
-	MIN       = "MIN"
-	COUNT     = "COUNT"
+	And         = "AND"
+	Or          = "OR"
+	In          = "IN"
+	Not         = "NOT"
+	Like        = "LIKE"
+	Eq          = "="
+	Ne          = "<>"
+	Gt          = ">"
+	Ge          = ">="
+	Lt          = "<"
+	Le          = "<="
+	IsNull      = "IS NULL"
+	IsNotNull   = "IS NOT NULL"
+	Between     = "BETWEEN"
+	Desc        = "DESC"
+	Asc         = "ASC"
+	As          = "AS"
+	SUM         = "SUM"
+	AVG         = "AVG"
+	MAX         = "MAX"
+	MIN         = "MIN"
+	COUNT       = "COUNT"
+	DISTINCT    = "DISTINCT"
+	COALESCE    = "COALESCE"
+	IFNULL      = "IFNULL"
+	CONCAT      = "CONCAT"
+	LOWER       = "LOWER"
+	UPPER       = "UPPER"
+	LENGTH      = "LENGTH"
+	ROUND       = "ROUND"
+	CASE        = "CASE"
+	WHEN        = "WHEN"
+	THEN        = "THEN"
+	ELSE        = "ELSE"
+	END         = "END"
+	ROW_NUMBER  = "ROW_NUMBER"
+	RANK        = "RANK"
+	DENSE_RANK  = "DENSE_RANK"
+	LAG         = "LAG"
+	LEAD        = "LEAD"
+	OVER        = "OVER"
+	PartitionBy = "PARTITION BY"
+	OrderBy     = "ORDER BY"
+	Union       = "UNION"
+	UnionAll    = "UNION ALL"
+	InnerJoin   = "INNER JOIN"
+	LeftJoin    = "LEFT JOIN"
+	RightJoin   = "RIGHT JOIN"
+	On          = "ON"
+	Exists      = "EXISTS"
 )
@@ -0,0 +1,276 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gplus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+)
+
+const (
+	auditIgnoreTag    = "AUDITIGNORE"
+	defaultAuditTable = "audit_log"
+)
+
+// AuditPlugin 审计插件，更新、删除记录时记录修改前的记录、更新的值、操作人以及执行的语句
+type AuditPlugin struct {
+	Operator func(ctx context.Context) any // 从上下文中获取操作人
+	Sink     AuditSink                     // 审计记录的写入方式
+	Tables   []string                      // 需要审计的表名，为空时审计所有表
+}
+
+// Audit 开启审计插件
+func Audit(audit *AuditPlugin) InitOptionFunc {
+	return func(o *InitOption) {
+		o.Audit = audit
+	}
+}
+
+func (ap *AuditPlugin) isAuditTable(tableName string) bool {
+	if len(ap.Tables) == 0 {
+		return true
+	}
+	for _, table := range ap.Tables {
+		if table == tableName {
+			return true
+		}
+	}
+	return false
+}
+
+// AuditRecord 审计记录，设置了 gplus:"auditIgnore" 标签的字段不会被记录
+type AuditRecord struct {
+	Table        string           `json:"table"`
+	Action       string           `json:"action"`       // update 或者 delete，逻辑删除同样为 delete
+	Operator     any              `json:"operator"`     // 操作人
+	Sql          string           `json:"sql"`          // 执行的语句，参数使用占位符，避免记录忽略的字段
+	Before       []map[string]any `json:"before"`       // 使用相同条件查询的修改前的记录
+	After        map[string]any   `json:"after"`        // 更新的值，物理删除时为nil
+	RowsAffected int64            `json:"rowsAffected"` // 影响的行数
+	CreatedAt    time.Time        `json:"createdAt"`
+}
+
+// AuditSink 审计记录的写入方式，tx 为执行修改语句的事务，写入失败时事务回滚
+type AuditSink interface {
+	Write(tx *gorm.DB, record *AuditRecord) error
+}
+
+// AuditTable 将审计记录写入审计表，与修改语句在同一个事务中，表名默认为 audit_log
+// 审计表字段：table_name、action、operator、sql_text、before_data、after_data、rows_affected、created_at
+type AuditTable struct {
+	Table string
+}
+
+func (s *AuditTable) Write(tx *gorm.DB, record *AuditRecord) error {
+	tableName := s.Table
+	if tableName == "" {
+		tableName = defaultAuditTable
+	}
+	before, err := json.Marshal(record.Before)
+	if err != nil {
+		return err
+	}
+	after, err := json.Marshal(record.After)
+	if err != nil {
+		return err
+	}
+	var operator string
+	if record.Operator != nil {
+		operator = fmt.Sprintf("%v", record.Operator)
+	}
+	return tx.Session(&gorm.Session{NewDB: true}).Table(tableName).Create(map[string]any{
+		"table_name":    record.Table,
+		"action":        record.Action,
+		"operator":      operator,
+		"sql_text":      record.Sql,
+		"before_data":   string(before),
+		"after_data":    string(after),
+		"rows_affected": record.RowsAffected,
+		"created_at":    record.CreatedAt,
+	}).Error
+}
+
+// AuditFunc 使用回调函数处理审计记录，返回错误时事务回滚
+type AuditFunc func(ctx context.Context, record *AuditRecord) error
+
+func (f AuditFunc) Write(tx *gorm.DB, record *AuditRecord) error {
+	return f(tx.Statement.Context, record)
+}
+
+// AuditChan 将审计记录发送到通道，通道已满时阻塞，直到上下文取消
+type AuditChan chan<- *AuditRecord
+
+func (c AuditChan) Write(tx *gorm.DB, record *AuditRecord) error {
+	ctx := tx.Statement.Context
+	select {
+	case c <- record:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// 缓存实体不需要审计的字段，key为实体类型
+var auditIgnoreCache sync.Map
+
+// 获取实体中设置了 gplus:"auditIgnore" 标签的字段名
+func getAuditIgnoreColumns[T any]() map[string]bool {
+	modelType := reflect.TypeOf((*T)(nil)).Elem()
+	if value, ok := auditIgnoreCache.Load(modelType); ok {
+		return value.(map[string]bool)
+	}
+	columns := make(map[string]bool)
+	if modelType.Kind() == reflect.Struct {
+		walkTagFields(modelType, func(field reflect.StructField, index []int, tagSetting map[string]string) bool {
+			if _, ok := tagSetting[auditIgnoreTag]; ok {
+				columns[parseColumnName(field)] = true
+			}
+			return true
+		})
+	}
+	auditIgnoreCache.Store(modelType, columns)
+	return columns
+}
+
+// withAudit 开启审计时，在同一个事务中使用相同的条件查询修改前的记录、执行修改语句并写入审计记录
+// 没有开启审计、表不需要审计或者 DryRun 时，直接执行修改语句
+func withAudit[T any](action string, buildQuery func() *QueryCond[T], opts []OptionFunc, exec func(opts ...OptionFunc) *gorm.DB) *gorm.DB {
+	ap := globalOption.Audit
+	if ap == nil || ap.Sink == nil {
+		return exec(opts...)
+	}
+	tableName := getTableName(new(T))
+	if !ap.isAuditTable(tableName) {
+		return exec(opts...)
+	}
+
+	// 不使用 getDb，选择和忽略的字段只作用于修改语句
+	option := getOption(opts)
+	db := globalDb
+	if option.Db != nil {
+		db = option.Db
+	}
+	if option.Ctx != nil {
+		db = db.WithContext(option.Ctx)
+	}
+	// DryRun 不会执行修改语句，不查询修改前的记录也不写入审计记录，避免预览语句时产生虚假的审计记录
+	if db.DryRun {
+		return exec(opts...)
+	}
+	// 没有查询条件或者使用了公共表表达式时，在查询修改前的记录之前拒绝执行，避免查询全表
+	q := buildQuery()
+	checkDb := db.Session(&gorm.Session{NewDB: true})
+	if !checkCteIfNeed(q, checkDb) || !checkGlobalUpdate(q, checkDb) {
+		return checkDb
+	}
+	var resultDb *gorm.DB
+	run := func(tx *gorm.DB) error {
+		// 修改前的记录查询所有字段，不使用选择和忽略的字段，加锁保证与修改的记录一致
+		beforeOpts := []OptionFunc{Db(tx)}
+		if option.IncludeDeleted {
+			beforeOpts = append(beforeOpts, IncludeDeleted())
+		}
+		var before []map[string]any
+		beforeDb := buildCondition[T](q, beforeOpts...).Clauses(clause.Locking{Strength: "UPDATE"})
+		if err := beforeDb.Find(&before).Error; err != nil {
+			resultDb = beforeDb
+			return err
+		}
+
+		resultDb = exec(append(append([]OptionFunc(nil), opts...), Db(tx))...)
+		if resultDb.Error != nil {
+			return resultDb.Error
+		}
+
+		ignoreColumns := getAuditIgnoreColumns[T]()
+		for _, row := range before {
+			for column := range ignoreColumns {
+				delete(row, column)
+			}
+		}
+		record := &AuditRecord{
+			Table:        tableName,
+			Action:       action,
+			Sql:          strings.TrimSpace(resultDb.Statement.SQL.String()),
+			Before:       before,
+			After:        getAuditAfter(resultDb, ignoreColumns),
+			RowsAffected: resultDb.RowsAffected,
+			CreatedAt:    time.Now(),
+		}
+		if ap.Operator != nil {
+			record.Operator = ap.Operator(tx.Statement.Context)
+		}
+		return ap.Sink.Write(tx, record)
+	}
+
+	err := db.Transaction(run)
+	if resultDb == nil {
+		// 开启事务失败，错误记录在新的会话中，不修改传入的db
+		resultDb = checkDb
+		resultDb.AddError(err)
+		return resultDb
+	}
+	if err != nil && resultDb.Error == nil {
+		resultDb.AddError(err)
+	}
+	return resultDb
+}
+
+// 从 SET 子句中获取更新的值，表达式记录为表达式语句
+func getAuditAfter(resultDb *gorm.DB, ignoreColumns map[string]bool) map[string]any {
+	c, ok := resultDb.Statement.Clauses["SET"]
+	if !ok {
+		return nil
+	}
+	set, ok := c.Expression.(clause.Set)
+	if !ok {
+		return nil
+	}
+	after := make(map[string]any, len(set))
+	for _, assignment := range set {
+		if ignoreColumns[assignment.Column.Name] {
+			continue
+		}
+		value := assignment.Value
+		if expr, isExpr := value.(clause.Expr); isExpr {
+			value = expr.SQL
+		}
+		after[assignment.Column.Name] = value
+	}
+	return after
+}
+
+// 根据实体的主键构建查询条件
+func getPkQuery[T any](entity *T) *QueryCond[T] {
+	q, _ := NewQuery[T]()
+	pkValue, _ := getPkValue(globalDb, entity)
+	q.Eq(getPkColumnName[T](), pkValue)
+	return q
+}
@@ -18,6 +18,8 @@
 package gplus
 
 import (
+	"github.com/acmestack/gorm-plus/constants"
+	"gorm.io/gorm"
 	"gorm.io/gorm/schema"
 	"reflect"
 	"sync"
@@ -30,12 +32,19 @@ var columnNameCache sync.Map
 // 缓存实体对象，主要给NewQuery方法返回使用
 var modelInstanceCache sync.Map
 
+// 缓存字段所属的表名，储存格式：key为字段指针值，value为表名，主要给连表查询拼接表名使用
+var columnTableCache sync.Map
+
 // Cache 缓存实体对象所有的字段名
 func Cache(models ...any) {
 	for _, model := range models {
 		columnNameMap := getColumnNameMap(model)
+		tableName := getTableName(model)
 		for pointer, columnName := range columnNameMap {
 			columnNameCache.Store(pointer, columnName)
+			if tableName != "" {
+				columnTableCache.Store(pointer, tableName)
+			}
 		}
 		// 缓存对象
 		modelTypeStr := reflect.TypeOf(model).Elem().String()
@@ -104,6 +113,63 @@ func getSubFieldColumnNameMap(valueOf reflect.Value, field reflect.StructField)
 	return result
 }
 
+// 解析实体对应的表名，解析失败返回空字符串
+func getTableName(model any) string {
+	stmt := &gorm.Statement{DB: globalDb}
+	if err := stmt.Parse(model); err != nil {
+		return ""
+	}
+	return stmt.Schema.Table
+}
+
+// 解析字段的gplus标签，格式与gorm标签一致，例如：gplus:"logicDelete;deleted:1;undeleted:0"
+func parseTagSetting(field reflect.StructField) map[string]string {
+	return schema.ParseTagSetting(field.Tag.Get("gplus"), ";")
+}
+
+// 遍历结构体的字段，如果存在嵌入实体，同样会递归遍历，嵌入实体本身不会传给 fn
+// fn 的参数为字段、从 modelType 开始的字段索引路径以及gplus标签，返回 false 时停止遍历
+func walkTagFields(modelType reflect.Type, fn func(field reflect.StructField, index []int, tagSetting map[string]string) bool) {
+	walkTagFieldsWithIndex(modelType, nil, fn)
+}
+
+func walkTagFieldsWithIndex(modelType reflect.Type, index []int, fn func(field reflect.StructField, index []int, tagSetting map[string]string) bool) bool {
+	for i := 0; i < modelType.NumField(); i++ {
+		field := modelType.Field(i)
+		fieldIndex := append(index[:len(index):len(index)], i)
+		if field.Anonymous {
+			subType := field.Type
+			if subType.Kind() == reflect.Ptr {
+				subType = subType.Elem()
+			}
+			if subType.Kind() == reflect.Struct && !walkTagFieldsWithIndex(subType, fieldIndex, fn) {
+				return false
+			}
+			continue
+		}
+		if !fn(field, fieldIndex, parseTagSetting(field)) {
+			return false
+		}
+	}
+	return true
+}
+
+// 查找设置了指定gplus标签的字段
+func lookUpTagField(modelType reflect.Type, tagKey string) (reflect.StructField, map[string]string, bool) {
+	var (
+		result    reflect.StructField
+		resultTag map[string]string
+		isFound   bool
+	)
+	walkTagFields(modelType, func(field reflect.StructField, index []int, tagSetting map[string]string) bool {
+		if _, ok := tagSetting[tagKey]; ok {
+			result, resultTag, isFound = field, tagSetting, true
+		}
+		return !isFound
+	})
+	return result, resultTag, isFound
+}
+
 // 解析字段名称
 func parseColumnName(field reflect.StructField) string {
 	tagSetting := schema.ParseTagSetting(field.Tag.Get("gorm"), ";")
@@ -132,3 +198,15 @@ func getColumnName(v any) string {
 	}
 	return columnName
 }
+
+// 获取带表名的字段名，例如：users.id，主要给连表查询使用
+func getTableColumnName(v any) string {
+	columnName := getColumnName(v)
+	valueOf := reflect.ValueOf(v)
+	if valueOf.Kind() == reflect.Pointer {
+		if tableName, ok := columnTableCache.Load(valueOf.Pointer()); ok {
+			return tableName.(string) + constants.Dot + columnName
+		}
+	}
+	return columnName
+}
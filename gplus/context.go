@@ -0,0 +1,176 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gplus
+
+import (
+	"context"
+	"database/sql"
+	"gorm.io/gorm"
+)
+
+// 以下方法为各个方法的上下文版本，第一个参数为上下文，等同于传入 Ctx(ctx) 选项
+
+// InsertCtx 插入一条记录
+func InsertCtx[T any](ctx context.Context, entity *T, opts ...OptionFunc) *gorm.DB {
+	return Insert[T](entity, append(opts[:len(opts):len(opts)], Ctx(ctx))...)
+}
+
+// InsertBatchCtx 批量插入多条记录
+func InsertBatchCtx[T any](ctx context.Context, entities []*T, opts ...OptionFunc) *gorm.DB {
+	return InsertBatch[T](entities, append(opts[:len(opts):len(opts)], Ctx(ctx))...)
+}
+
+// InsertBatchSizeCtx 批量插入多条记录
+func InsertBatchSizeCtx[T any](ctx context.Context, entities []*T, batchSize int, opts ...OptionFunc) *gorm.DB {
+	return InsertBatchSize[T](entities, batchSize, append(opts[:len(opts):len(opts)], Ctx(ctx))...)
+}
+
+// UpsertCtx 插入一条记录，冲突时根据 conflict 更新记录或者不做处理
+func UpsertCtx[T any](ctx context.Context, entity *T, conflict *Conflict, opts ...OptionFunc) *gorm.DB {
+	return Upsert[T](entity, conflict, append(opts[:len(opts):len(opts)], Ctx(ctx))...)
+}
+
+// UpsertBatchCtx 批量插入多条记录，冲突时根据 conflict 更新记录或者不做处理
+func UpsertBatchCtx[T any](ctx context.Context, entities []*T, conflict *Conflict, opts ...OptionFunc) *gorm.DB {
+	return UpsertBatch[T](entities, conflict, append(opts[:len(opts):len(opts)], Ctx(ctx))...)
+}
+
+// DeleteByIdCtx 根据 ID 删除记录
+func DeleteByIdCtx[T any](ctx context.Context, id any, opts ...OptionFunc) *gorm.DB {
+	return DeleteById[T](id, append(opts[:len(opts):len(opts)], Ctx(ctx))...)
+}
+
+// DeleteByIdsCtx 根据 ID 批量删除记录
+func DeleteByIdsCtx[T any](ctx context.Context, ids any, opts ...OptionFunc) *gorm.DB {
+	return DeleteByIds[T](ids, append(opts[:len(opts):len(opts)], Ctx(ctx))...)
+}
+
+// DeleteCtx 根据条件删除记录
+func DeleteCtx[T any](ctx context.Context, q *QueryCond[T], opts ...OptionFunc) *gorm.DB {
+	return Delete[T](q, append(opts[:len(opts):len(opts)], Ctx(ctx))...)
+}
+
+// RestoreCtx 根据条件恢复逻辑删除的记录
+func RestoreCtx[T any](ctx context.Context, q *QueryCond[T], opts ...OptionFunc) *gorm.DB {
+	return Restore[T](q, append(opts[:len(opts):len(opts)], Ctx(ctx))...)
+}
+
+// RestoreByIdCtx 根据 ID 恢复逻辑删除的记录
+func RestoreByIdCtx[T any](ctx context.Context, id any, opts ...OptionFunc) *gorm.DB {
+	return RestoreById[T](id, append(opts[:len(opts):len(opts)], Ctx(ctx))...)
+}
+
+// UpdateByIdCtx 根据 ID 更新,默认零值不更新
+func UpdateByIdCtx[T any](ctx context.Context, entity *T, opts ...OptionFunc) *gorm.DB {
+	return UpdateById[T](entity, append(opts[:len(opts):len(opts)], Ctx(ctx))...)
+}
+
+// UpdateZeroByIdCtx 根据 ID 零值更新
+func UpdateZeroByIdCtx[T any](ctx context.Context, entity *T, opts ...OptionFunc) *gorm.DB {
+	return UpdateZeroById[T](entity, append(opts[:len(opts):len(opts)], Ctx(ctx))...)
+}
+
+// UpdateBatchByIdCtx 根据 ID 批量更新,默认零值不更新
+func UpdateBatchByIdCtx[T any](ctx context.Context, entities []*T, opts ...OptionFunc) *gorm.DB {
+	return UpdateBatchById[T](entities, append(opts[:len(opts):len(opts)], Ctx(ctx))...)
+}
+
+// UpdateCtx 根据 Map 更新
+func UpdateCtx[T any](ctx context.Context, q *QueryCond[T], opts ...OptionFunc) *gorm.DB {
+	return Update[T](q, append(opts[:len(opts):len(opts)], Ctx(ctx))...)
+}
+
+// SelectByIdCtx 根据 ID 查询单条记录
+func SelectByIdCtx[T any](ctx context.Context, id any, opts ...OptionFunc) (*T, *gorm.DB) {
+	return SelectById[T](id, append(opts[:len(opts):len(opts)], Ctx(ctx))...)
+}
+
+// SelectByIdsCtx 根据 ID 查询多条记录
+func SelectByIdsCtx[T any](ctx context.Context, ids any, opts ...OptionFunc) ([]*T, *gorm.DB) {
+	return SelectByIds[T](ids, append(opts[:len(opts):len(opts)], Ctx(ctx))...)
+}
+
+// SelectOneCtx 根据条件查询单条记录
+func SelectOneCtx[T any](ctx context.Context, q *QueryCond[T], opts ...OptionFunc) (*T, *gorm.DB) {
+	return SelectOne[T](q, append(opts[:len(opts):len(opts)], Ctx(ctx))...)
+}
+
+// SelectListCtx 根据条件查询多条记录
+func SelectListCtx[T any](ctx context.Context, q *QueryCond[T], opts ...OptionFunc) ([]*T, *gorm.DB) {
+	return SelectList[T](q, append(opts[:len(opts):len(opts)], Ctx(ctx))...)
+}
+
+// SelectIterCtx 根据条件查询，返回逐行读取的迭代器
+func SelectIterCtx[T any](ctx context.Context, q *QueryCond[T], opts ...OptionFunc) (*Iterator[T], *gorm.DB) {
+	return SelectIter[T](q, append(opts[:len(opts):len(opts)], Ctx(ctx))...)
+}
+
+// SelectEachCtx 根据条件查询，逐行回调处理
+func SelectEachCtx[T any](ctx context.Context, q *QueryCond[T], fn func(*T) error, opts ...OptionFunc) *gorm.DB {
+	return SelectEach[T](q, fn, append(opts[:len(opts):len(opts)], Ctx(ctx))...)
+}
+
+// SelectInChunksCtx 根据主键分批查询
+func SelectInChunksCtx[T any](ctx context.Context, q *QueryCond[T], chunkSize int, fn func([]*T) error, opts ...OptionFunc) *gorm.DB {
+	return SelectInChunks[T](q, chunkSize, fn, append(opts[:len(opts):len(opts)], Ctx(ctx))...)
+}
+
+// SelectPageCtx 根据条件分页查询记录
+func SelectPageCtx[T any](ctx context.Context, page *Page[T], q *QueryCond[T], opts ...OptionFunc) (*Page[T], *gorm.DB) {
+	return SelectPage[T](page, q, append(opts[:len(opts):len(opts)], Ctx(ctx))...)
+}
+
+// SelectStreamingPageCtx 根据条件分页查询记录
+func SelectStreamingPageCtx[T any, V Comparable](ctx context.Context, page *StreamingPage[T, V], q *QueryCond[T], opts ...OptionFunc) (*StreamingPage[T, V], *gorm.DB) {
+	return SelectStreamingPage[T, V](page, q, append(opts[:len(opts):len(opts)], Ctx(ctx))...)
+}
+
+// SelectCountCtx 根据条件查询记录数量
+func SelectCountCtx[T any](ctx context.Context, q *QueryCond[T], opts ...OptionFunc) (int64, *gorm.DB) {
+	return SelectCount[T](q, append(opts[:len(opts):len(opts)], Ctx(ctx))...)
+}
+
+// ExistsCtx 根据条件判断记录是否存在
+func ExistsCtx[T any](ctx context.Context, q *QueryCond[T], opts ...OptionFunc) (bool, *gorm.DB) {
+	return Exists[T](q, append(opts[:len(opts):len(opts)], Ctx(ctx))...)
+}
+
+// SelectPageGenericCtx 根据传入的泛型封装分页记录
+func SelectPageGenericCtx[T any, R any](ctx context.Context, page *Page[R], q *QueryCond[T], opts ...OptionFunc) (*Page[R], *gorm.DB) {
+	return SelectPageGeneric[T, R](page, q, append(opts[:len(opts):len(opts)], Ctx(ctx))...)
+}
+
+// SelectStreamingPageGenericCtx 根据传入的泛型封装分页记录
+func SelectStreamingPageGenericCtx[T any, R any, V Comparable](ctx context.Context, page *StreamingPage[R, V], q *QueryCond[T], opts ...OptionFunc) (*StreamingPage[R, V], *gorm.DB) {
+	return SelectStreamingPageGeneric[T, R, V](page, q, append(opts[:len(opts):len(opts)], Ctx(ctx))...)
+}
+
+// SelectGenericCtx 根据传入的泛型封装记录
+func SelectGenericCtx[T any, R any](ctx context.Context, q *QueryCond[T], opts ...OptionFunc) (R, *gorm.DB) {
+	return SelectGeneric[T, R](q, append(opts[:len(opts):len(opts)], Ctx(ctx))...)
+}
+
+// BeginCtx 开启事务
+func BeginCtx(ctx context.Context, opts ...*sql.TxOptions) *gorm.DB {
+	return getDb(Ctx(ctx)).Begin(opts...)
+}
+
+// TxCtx 事务
+func TxCtx(ctx context.Context, txFunc func(tx *gorm.DB) error, opts ...OptionFunc) error {
+	return Tx(txFunc, append(opts[:len(opts):len(opts)], Ctx(ctx))...)
+}
@@ -0,0 +1,120 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gplus
+
+import (
+	"errors"
+	"github.com/acmestack/gorm-plus/constants"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrCteNotSupported 公共表表达式只能用于查询，更新、删除和恢复时使用 With 或者 FromCte 返回该错误
+var ErrCteNotSupported = errors.New("gplus: with and from cte are only supported in select")
+
+// cte 公共表表达式，递归查询不为空时使用 UNION ALL 连接锚点查询和递归查询
+type cte struct {
+	name           string
+	query          subQuery
+	recursiveQuery subQuery
+}
+
+// With 公共表表达式：WITH 名称 AS (子查询)，外层查询通过 FromCte 或连表引用该名称
+func (q *QueryCond[T]) With(name string, sub subQuery) *QueryCond[T] {
+	q.ctes = append(q.ctes, &cte{name: name, query: sub})
+	return q
+}
+
+// WithRecursive 递归公共表表达式：WITH RECURSIVE 名称 AS (锚点查询 UNION ALL 递归查询)
+// 递归查询通过连表引用名称，例如：recursive.InnerJoin("tree", &c.ParentId, "tree.id")
+// 锚点查询和递归查询需要查询相同的字段
+func (q *QueryCond[T]) WithRecursive(name string, anchor subQuery, recursive subQuery) *QueryCond[T] {
+	q.ctes = append(q.ctes, &cte{name: name, query: anchor, recursiveQuery: recursive})
+	return q
+}
+
+// FromCte 从公共表表达式中查询：FROM 名称，公共表表达式已经包含逻辑删除和租户条件，外层查询不再重复添加
+// Tips: 名称需要通过 With 或者 WithRecursive 声明，否则外层查询仍然会添加逻辑删除和租户条件
+func (q *QueryCond[T]) FromCte(name string) *QueryCond[T] {
+	q.fromTable = name
+	return q
+}
+
+// isFromCte 是否从声明的公共表表达式中查询
+func (q *QueryCond[T]) isFromCte() bool {
+	for _, c := range q.ctes {
+		if c.name == q.fromTable {
+			return true
+		}
+	}
+	return false
+}
+
+// withClause gorm 没有 WITH 子句，通过自定义子句在 SELECT 之前生成公共表表达式
+type withClause struct {
+	ctes []*cte
+	db   *gorm.DB
+}
+
+func (w withClause) Name() string {
+	return "WITH"
+}
+
+func (w withClause) MergeClause(c *clause.Clause) {
+	c.Expression = w
+}
+
+func (w withClause) Build(builder clause.Builder) {
+	for _, c := range w.ctes {
+		if c.recursiveQuery != nil {
+			builder.WriteString("RECURSIVE ")
+			break
+		}
+	}
+	for i, c := range w.ctes {
+		if i > 0 {
+			builder.WriteString(constants.Comma + " ")
+		}
+		builder.WriteQuoted(c.name)
+		builder.WriteString(" " + constants.As + " " + constants.LeftBracket)
+		builder.AddVar(builder, c.query.buildSubQuery(w.db))
+		if c.recursiveQuery != nil {
+			builder.WriteString(" UNION ALL ")
+			builder.AddVar(builder, c.recursiveQuery.buildSubQuery(w.db))
+		}
+		builder.WriteString(constants.RightBracket)
+	}
+}
+
+// checkCteIfNeed 更新、删除和恢复时不支持公共表表达式，返回 ErrCteNotSupported
+func checkCteIfNeed[T any](q *QueryCond[T], resultDb *gorm.DB) bool {
+	if q == nil || (len(q.ctes) == 0 && q.fromTable == "") {
+		return true
+	}
+	resultDb.AddError(ErrCteNotSupported)
+	return false
+}
+
+// addWithIfNeed 添加公共表表达式，WITH 子句只在查询语句中生成，更新、删除等语句由 checkCteIfNeed 拒绝
+func addWithIfNeed[T any](q *QueryCond[T], resultDb *gorm.DB) {
+	if len(q.ctes) == 0 {
+		return
+	}
+	resultDb.Clauses(withClause{ctes: q.ctes, db: resultDb.Session(&gorm.Session{NewDB: true})})
+	resultDb.Statement.BuildClauses = append([]string{"WITH"}, resultDb.Callback().Query().Clauses...)
+}
@@ -18,9 +18,9 @@ package gplus
 
 import (
 	"database/sql"
-	"fmt"
 	"github.com/acmestack/gorm-plus/constants"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/schema"
 	"gorm.io/gorm/utils"
 	"reflect"
@@ -29,10 +29,15 @@ import (
 )
 
 var globalDb *gorm.DB
+var globalOption InitOption
 var defaultBatchSize = 1000
 
-func Init(db *gorm.DB) {
+func Init(db *gorm.DB, opts ...InitOptionFunc) {
 	globalDb = db
+	globalOption = InitOption{}
+	for _, op := range opts {
+		op(&globalOption)
+	}
 }
 
 type Page[T any] struct {
@@ -64,13 +69,21 @@ type Comparable interface {
 }
 
 type StreamingPage[T any, V Comparable] struct {
-	ColumnName any   `json:"columnName"` // 进行分页的列字段名称
-	StartValue V     `json:"startValue"` // 分页起始值
-	Limit      int   `json:"limit"`      // 页大小
-	Forward    bool  `json:"forward"`    // 上下页翻页标识
-	Total      int64 `json:"total"`      // 总记录数
-	Records    []*T  `json:"records"`    // 查询记录
-	RecordsMap []T   `json:"recordsMap"` // 查询记录Map
+	ColumnName     any       `json:"columnName"`     // 进行分页的列字段名称
+	StartValue     V         `json:"startValue"`     // 分页起始值
+	Limit          int       `json:"limit"`          // 页大小
+	Forward        bool      `json:"forward"`        // 上下页翻页标识
+	Total          int64     `json:"total"`          // 总记录数
+	Records        []*T      `json:"records"`        // 查询记录
+	RecordsMap     []T       `json:"recordsMap"`     // 查询记录Map
+	SortKeys       []SortKey `json:"-"`              // 排序字段，设置后忽略 ColumnName
+	Cursor         string    `json:"cursor"`         // 分页游标，设置后忽略 StartValue
+	HasNext        bool      `json:"hasNext"`        // 是否有下一页
+	HasPrev        bool      `json:"hasPrev"`        // 是否有上一页
+	NextStartValue V         `json:"nextStartValue"` // 下一页的起始值
+	PrevStartValue V         `json:"prevStartValue"` // 上一页的起始值
+	NextCursor     string    `json:"nextCursor"`     // 下一页的游标
+	PrevCursor     string    `json:"prevCursor"`     // 上一页的游标
 }
 
 func NewStreamingPage[T any, V Comparable](columnName any, startValue V, limit int) *StreamingPage[T, V] {
@@ -85,6 +98,20 @@ func NewStreamingPage[T any, V Comparable](columnName any, startValue V, limit i
 // Insert 插入一条记录
 func Insert[T any](entity *T, opts ...OptionFunc) *gorm.DB {
 	db := getDb(opts...)
+	if err := fillTenantIfNeed(db, entity); err != nil {
+		db.AddError(err)
+		return db
+	}
+	if err := fillInsertIfNeed(db, entity); err != nil {
+		db.AddError(err)
+		return db
+	}
+	restore, err := encryptEntitiesIfNeed(db, entity)
+	if err != nil {
+		db.AddError(err)
+		return db
+	}
+	defer restore()
 	resultDb := db.Create(entity)
 	return resultDb
 }
@@ -95,6 +122,20 @@ func InsertBatch[T any](entities []*T, opts ...OptionFunc) *gorm.DB {
 	if len(entities) == 0 {
 		return db
 	}
+	if err := fillTenantIfNeed(db, entities...); err != nil {
+		db.AddError(err)
+		return db
+	}
+	if err := fillInsertIfNeed(db, entities...); err != nil {
+		db.AddError(err)
+		return db
+	}
+	restore, err := encryptEntitiesIfNeed(db, entities...)
+	if err != nil {
+		db.AddError(err)
+		return db
+	}
+	defer restore()
 	resultDb := db.CreateInBatches(entities, defaultBatchSize)
 	return resultDb
 }
@@ -108,13 +149,47 @@ func InsertBatchSize[T any](entities []*T, batchSize int, opts ...OptionFunc) *g
 	if batchSize <= 0 {
 		batchSize = defaultBatchSize
 	}
+	if err := fillTenantIfNeed(db, entities...); err != nil {
+		db.AddError(err)
+		return db
+	}
+	if err := fillInsertIfNeed(db, entities...); err != nil {
+		db.AddError(err)
+		return db
+	}
+	restore, err := encryptEntitiesIfNeed(db, entities...)
+	if err != nil {
+		db.AddError(err)
+		return db
+	}
+	defer restore()
 	resultDb := db.CreateInBatches(entities, batchSize)
 	return resultDb
 }
 
-// DeleteById 根据 ID 删除记录
+// DeleteById 根据 ID 删除记录，如果实体配置了逻辑删除，则进行逻辑删除
 func DeleteById[T any](id any, opts ...OptionFunc) *gorm.DB {
+	if getLogicDelete[T]() != nil {
+		q, _ := NewQuery[T]()
+		q.Eq(getPkColumnName[T](), id)
+		return Delete[T](q, opts...)
+	}
+	buildQuery := func() *QueryCond[T] {
+		q, _ := NewQuery[T]()
+		q.Eq(getPkColumnName[T](), id)
+		return q
+	}
+	return withAudit(AuditDelete, buildQuery, opts, func(opts ...OptionFunc) *gorm.DB {
+		return deleteById[T](id, opts...)
+	})
+}
+
+func deleteById[T any](id any, opts ...OptionFunc) *gorm.DB {
 	db := getDb(opts...)
+	if err := addTenantIfNeed[T](nil, db); err != nil {
+		db.AddError(err)
+		return db
+	}
 	var entity T
 	resultDb := db.Where(getPkColumnName[T](), id).Delete(&entity)
 	return resultDb
@@ -128,32 +203,129 @@ func DeleteByIds[T any](ids any, opts ...OptionFunc) *gorm.DB {
 	return resultDb
 }
 
-// Delete 根据条件删除记录
+// Delete 根据条件删除记录，如果实体配置了逻辑删除，则进行逻辑删除
 func Delete[T any](q *QueryCond[T], opts ...OptionFunc) *gorm.DB {
+	return withAudit(AuditDelete, func() *QueryCond[T] { return q }, opts, func(opts ...OptionFunc) *gorm.DB {
+		return deleteByCond[T](q, opts...)
+	})
+}
+
+func deleteByCond[T any](q *QueryCond[T], opts ...OptionFunc) *gorm.DB {
 	var entity T
 	resultDb := buildCondition[T](q, opts...)
+	if !checkCteIfNeed(q, resultDb) || !checkGlobalUpdate(q, resultDb) {
+		return resultDb
+	}
+	if ld := getLogicDelete[T](); ld != nil {
+		resultDb.Update(ld.columnName, ld.deletedValue)
+		return resultDb
+	}
 	resultDb.Delete(&entity)
 	return resultDb
 }
 
 // UpdateById 根据 ID 更新,默认零值不更新
+// 如果实体设置了版本号字段，则进行乐观锁更新，更新失败返回 ErrOptimisticLock
 func UpdateById[T any](entity *T, opts ...OptionFunc) *gorm.DB {
+	return withAudit(AuditUpdate, func() *QueryCond[T] { return getPkQuery(entity) }, opts, func(opts ...OptionFunc) *gorm.DB {
+		return updateById(entity, opts...)
+	})
+}
+
+func updateById[T any](entity *T, opts ...OptionFunc) *gorm.DB {
 	db := getDb(opts...)
+	if err := addTenantIfNeed[T](nil, db); err != nil {
+		db.AddError(err)
+		return db
+	}
+	if err := fillUpdateIfNeed(db, entity); err != nil {
+		db.AddError(err)
+		return db
+	}
+	restore, err := encryptEntitiesIfNeed(db, entity)
+	if err != nil {
+		db.AddError(err)
+		return db
+	}
+	defer restore()
+	if vf := getVersionField[T](); vf != nil {
+		return updateWithVersion(db, entity, vf)
+	}
 	resultDb := db.Model(entity).Updates(entity)
 	return resultDb
 }
 
 // UpdateZeroById 根据 ID 零值更新
+// 如果实体设置了版本号字段，则进行乐观锁更新，更新失败返回 ErrOptimisticLock
 func UpdateZeroById[T any](entity *T, opts ...OptionFunc) *gorm.DB {
+	return withAudit(AuditUpdate, func() *QueryCond[T] { return getPkQuery(entity) }, opts, func(opts ...OptionFunc) *gorm.DB {
+		return updateZeroById(entity, opts...)
+	})
+}
+
+func updateZeroById[T any](entity *T, opts ...OptionFunc) *gorm.DB {
 	db := getDb(opts...)
 
 	// 如果用户没有设置选择更新的字段，默认更新所有的字段，包括零值更新
 	updateAllIfNeed(entity, opts, db)
 
+	if err := addTenantIfNeed[T](nil, db); err != nil {
+		db.AddError(err)
+		return db
+	}
+	if err := fillUpdateIfNeed(db, entity); err != nil {
+		db.AddError(err)
+		return db
+	}
+	restore, err := encryptEntitiesIfNeed(db, entity)
+	if err != nil {
+		db.AddError(err)
+		return db
+	}
+	defer restore()
+	if vf := getVersionField[T](); vf != nil {
+		return updateWithVersion(db, entity, vf)
+	}
 	resultDb := db.Model(entity).Updates(entity)
 	return resultDb
 }
 
+// UpdateBatchById 根据 ID 批量更新,默认零值不更新
+// 所有记录在同一个事务中更新，任意一条记录更新失败(包括乐观锁更新失败)则全部回滚
+func UpdateBatchById[T any](entities []*T, opts ...OptionFunc) *gorm.DB {
+	db := getDb(opts...)
+	if len(entities) == 0 {
+		return db
+	}
+	vf := getVersionField[T]()
+	var oldVersions []reflect.Value
+	var rowsAffected int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, entity := range entities {
+			if vf != nil {
+				versionValue := reflect.ValueOf(entity).Elem().FieldByName(vf.fieldName)
+				oldVersions = append(oldVersions, reflect.ValueOf(versionValue.Interface()))
+			}
+			resultDb := UpdateById[T](entity, append(opts[:len(opts):len(opts)], Db(tx))...)
+			if resultDb.Error != nil {
+				return resultDb.Error
+			}
+			rowsAffected += resultDb.RowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		// 事务回滚后，已经更新的实体版本号需要恢复为旧的版本号
+		for i, oldVersion := range oldVersions {
+			reflect.ValueOf(entities[i]).Elem().FieldByName(vf.fieldName).Set(oldVersion)
+		}
+		db.AddError(err)
+		return db
+	}
+	db.RowsAffected = rowsAffected
+	return db
+}
+
 func updateAllIfNeed(entity any, opts []OptionFunc, db *gorm.DB) {
 	option := getOption(opts)
 	if len(option.Selects) == 0 {
@@ -167,9 +339,42 @@ func updateAllIfNeed(entity any, opts []OptionFunc, db *gorm.DB) {
 }
 
 // Update 根据 Map 更新
+// 如果实体设置了版本号字段，则自动更新版本号：SET version = version + 1
+// 如果条件中包含 版本号 = 值 的条件，更新失败返回 ErrOptimisticLock
 func Update[T any](q *QueryCond[T], opts ...OptionFunc) *gorm.DB {
+	return withAudit(AuditUpdate, func() *QueryCond[T] { return q }, opts, func(opts ...OptionFunc) *gorm.DB {
+		return updateByCond(q, opts...)
+	})
+}
+
+func updateByCond[T any](q *QueryCond[T], opts ...OptionFunc) *gorm.DB {
 	resultDb := buildCondition[T](q, opts...)
-	resultDb.Updates(&q.updateMap)
+	if !checkCteIfNeed(q, resultDb) || !checkGlobalUpdate(q, resultDb) {
+		return resultDb
+	}
+	// 复制一份更新的字段，避免修改查询条件
+	updateMap := make(map[string]any, len(q.updateMap)+1)
+	for column, value := range q.updateMap {
+		updateMap[column] = value
+	}
+	if err := fillUpdateMapIfNeed[T](resultDb, updateMap); err != nil {
+		resultDb.AddError(err)
+		return resultDb
+	}
+	if err := encryptUpdateMapIfNeed[T](resultDb, updateMap); err != nil {
+		resultDb.AddError(err)
+		return resultDb
+	}
+	vf := getVersionField[T]()
+	if vf == nil {
+		resultDb.Updates(&updateMap)
+		return resultDb
+	}
+	updateMap[vf.columnName] = gorm.Expr(vf.columnName + " + 1")
+	resultDb.Updates(&updateMap)
+	if resultDb.Error == nil && resultDb.RowsAffected == 0 && !resultDb.DryRun && containsEqColumn(q.queryExpressions, vf.columnName) {
+		resultDb.AddError(ErrOptimisticLock)
+	}
 	return resultDb
 }
 
@@ -179,7 +384,9 @@ func SelectById[T any](id any, opts ...OptionFunc) (*T, *gorm.DB) {
 	q.Eq(getPkColumnName[T](), id)
 	var entity T
 	resultDb := buildCondition(q, opts...)
-	return &entity, resultDb.Take(&entity)
+	resultDb.Take(&entity)
+	decryptResult(resultDb, &entity)
+	return &entity, resultDb
 }
 
 // SelectByIds 根据 ID 查询多条记录
@@ -193,7 +400,9 @@ func SelectByIds[T any](ids any, opts ...OptionFunc) ([]*T, *gorm.DB) {
 func SelectOne[T any](q *QueryCond[T], opts ...OptionFunc) (*T, *gorm.DB) {
 	var entity T
 	resultDb := buildCondition(q, opts...)
-	return &entity, resultDb.Take(&entity)
+	resultDb.Take(&entity)
+	decryptResult(resultDb, &entity)
+	return &entity, resultDb
 }
 
 // SelectList 根据条件查询多条记录
@@ -201,6 +410,7 @@ func SelectList[T any](q *QueryCond[T], opts ...OptionFunc) ([]*T, *gorm.DB) {
 	resultDb := buildCondition(q, opts...)
 	var results []*T
 	resultDb.Find(&results)
+	decryptResult(resultDb, results)
 	return results, resultDb
 }
 
@@ -220,6 +430,8 @@ func SelectPage[T any](page *Page[T], q *QueryCond[T], opts ...OptionFunc) (*Pag
 	resultDb := buildCondition(q, opts...)
 	var results []*T
 	resultDb.Scopes(paginate(page)).Find(&results)
+	decryptResult(resultDb, results)
+	maskIfNeed(resultDb, results, opts)
 	page.Records = results
 	return page, resultDb
 }
@@ -239,8 +451,10 @@ func SelectStreamingPage[T any, V Comparable](page *StreamingPage[T, V], q *Quer
 
 	resultDb := buildCondition(q, opts...)
 	var results []*T
-	resultDb.Scopes(streamingPaginate(page)).Find(&results)
-	page.Records = results
+	resultDb.Scopes(streamingPaginate[T](page)).Find(&results)
+	page.Records = fillStreamingPage(page, resultDb, results)
+	decryptResult(resultDb, page.Records)
+	maskIfNeed(resultDb, page.Records, opts)
 	return page, resultDb
 }
 
@@ -283,6 +497,8 @@ func SelectPageGeneric[T any, R any](page *Page[R], q *QueryCond[T], opts ...Opt
 	default:
 		var results []*R
 		resultDb.Scopes(paginate(page)).Scan(&results)
+		decryptResult(resultDb, results)
+		maskIfNeed(resultDb, results, opts)
 		page.Records = results
 	}
 	return page, resultDb
@@ -306,12 +522,14 @@ func SelectStreamingPageGeneric[T any, R any, V Comparable](page *StreamingPage[
 	switch any(r).(type) {
 	case map[string]any:
 		var results []R
-		resultDb.Scopes(streamingPaginate(page)).Scan(&results)
-		page.RecordsMap = results
+		resultDb.Scopes(streamingPaginate[T](page)).Scan(&results)
+		page.RecordsMap = fillStreamingPage(page, resultDb, results)
 	default:
 		var results []*R
-		resultDb.Scopes(streamingPaginate(page)).Scan(&results)
-		page.Records = results
+		resultDb.Scopes(streamingPaginate[T](page)).Scan(&results)
+		page.Records = fillStreamingPage(page, resultDb, results)
+		decryptResult(resultDb, page.Records)
+		maskIfNeed(resultDb, page.Records, opts)
 	}
 	return page, resultDb
 }
@@ -322,7 +540,9 @@ func SelectStreamingPageGeneric[T any, R any, V Comparable](page *StreamingPage[
 func SelectGeneric[T any, R any](q *QueryCond[T], opts ...OptionFunc) (R, *gorm.DB) {
 	var entity R
 	resultDb := buildCondition(q, opts...)
-	return entity, resultDb.Scan(&entity)
+	resultDb.Scan(&entity)
+	decryptResult(resultDb, &entity)
+	return entity, resultDb
 }
 
 func Begin(opts ...*sql.TxOptions) *gorm.DB {
@@ -352,60 +572,98 @@ func paginate[T any](p *Page[T]) func(db *gorm.DB) *gorm.DB {
 	}
 }
 
-// streamingPaginate 流式分页，根据自增ID、雪花ID、时间等数值类型或者时间类型分页
-// Tips: 相比于 offset 分页性能更好，走的是 range，缺点是没办法跳页查询
-func streamingPaginate[T any, V Comparable](p *StreamingPage[T, V]) func(db *gorm.DB) *gorm.DB {
-	column := getColumnName(p.ColumnName)
-	startValue := p.StartValue
-	limit := p.Limit
-	return func(db *gorm.DB) *gorm.DB {
-		// 下一页
-		if p.Forward {
-			return db.Where(fmt.Sprintf("%v > ?", column), startValue).Limit(limit)
-		}
-		// 上一页
-		return db.Where(fmt.Sprintf("%v < ?", column), startValue).Order(fmt.Sprintf("%v DESC", column)).Limit(limit)
-	}
-}
-
 func buildCondition[T any](q *QueryCond[T], opts ...OptionFunc) *gorm.DB {
 	db := getDb(opts...)
 	resultDb := db.Model(new(T))
+	// 加密字段的条件参数需要加密，使用加密后的查询条件副本
+	q, err := encryptQueryIfNeed(q, resultDb)
+	if err != nil {
+		resultDb.AddError(err)
+	}
 	if q != nil {
-		// 这里清空参数，避免用户重复使用一个query条件
-		q.queryArgs = make([]any, 0)
+		if q.fromQuery != nil {
+			resultDb.Table("(?) "+constants.As+" "+q.fromAlias, q.fromQuery.buildSubQuery(resultDb.Session(&gorm.Session{NewDB: true})))
+		}
+
+		if q.fromTable != "" {
+			resultDb.Table(q.fromTable)
+		}
+
+		addWithIfNeed(q, resultDb)
 
+		// 连表查询时字段名带上表名，在构建时处理，与调用 Join 和 Select 等方法的顺序无关
+		qualified := len(q.joins) > 0
 		if len(q.distinctColumns) > 0 {
-			resultDb.Distinct(q.distinctColumns)
+			distinctColumns, _ := buildColumns(q.distinctColumns, qualified)
+			resultDb.Distinct(distinctColumns)
 		}
 
 		if len(q.selectColumns) > 0 {
-			resultDb.Select(q.selectColumns)
+			selectColumns, selectArgs := buildColumns(q.selectColumns, qualified)
+			if len(selectArgs) > 0 {
+				selectSql, selectArgs := buildSelectExpr(selectColumns, selectArgs)
+				resultDb.Select(selectSql, selectArgs...)
+			} else {
+				resultDb.Select(selectColumns)
+			}
 		}
 
 		if len(q.omitColumns) > 0 {
-			resultDb.Omit(q.omitColumns...)
+			omitColumns, _ := buildColumns(q.omitColumns, qualified)
+			resultDb.Omit(omitColumns...)
+		}
+
+		// 连表查询时，条件字段需要带上表名，子查询使用当前db的会话构建，保持上下文一致
+		option := buildSqlOption{qualified: qualified, db: resultDb.Session(&gorm.Session{NewDB: true}), includeDeleted: getOption(opts).IncludeDeleted}
+		for _, join := range q.joins {
+			joinSql, joinArgs, err := buildJoinSqlAndArgs[T](join, option)
+			if err != nil {
+				resultDb.AddError(err)
+			}
+			resultDb.Joins(joinSql, joinArgs...)
 		}
 
 		expressions := q.queryExpressions
 		if len(expressions) > 0 {
+			// 参数使用局部变量，构建sql时不修改查询条件，同一个查询条件可以重复、并发使用
 			var sqlBuilder strings.Builder
-			q.queryArgs = buildSqlAndArgs[T](expressions, &sqlBuilder, q.queryArgs)
-			resultDb.Where(sqlBuilder.String(), q.queryArgs...)
+			queryArgs := buildSqlAndArgs[T](expressions, &sqlBuilder, make([]any, 0), option)
+			resultDb.Where(sqlBuilder.String(), queryArgs...)
 		}
 
-		if q.orderBuilder.Len() > 0 {
-			resultDb.Order(q.orderBuilder.String())
+		if len(q.orders) > 0 {
+			orderSql, orderArgs := buildOrderSql(q.orders, qualified)
+			if len(orderArgs) > 0 {
+				resultDb.Clauses(clause.OrderBy{Expression: clause.Expr{SQL: orderSql, Vars: orderArgs}})
+			} else {
+				resultDb.Order(orderSql)
+			}
 		}
 
-		if q.groupBuilder.Len() > 0 {
-			resultDb.Group(q.groupBuilder.String())
+		groupColumns, groupArgs := buildColumns(q.groupColumns, qualified)
+		if len(groupColumns) > 0 && len(groupArgs) == 0 {
+			resultDb.Group(strings.Join(groupColumns, constants.Comma))
 		}
 
 		if q.havingBuilder.Len() > 0 {
 			resultDb.Having(q.havingBuilder.String(), q.havingArgs...)
 		}
 
+		// 每个 HavingCond 单独设置，由 gorm 使用 AND 连接，包含 OR 的条件会被加上括号
+		for _, havingQuery := range q.havingConds {
+			if len(havingQuery.queryExpressions) == 0 {
+				continue
+			}
+			var havingBuilder strings.Builder
+			havingArgs := buildSqlAndArgs[T](havingQuery.queryExpressions, &havingBuilder, make([]any, 0), option)
+			resultDb.Having(havingBuilder.String(), havingArgs...)
+		}
+
+		// 分组包含参数时，需要在设置 HAVING 之后添加，合并已经设置的 HAVING 条件
+		if len(groupArgs) > 0 {
+			resultDb.Clauses(groupByExpr{expr: clause.Expr{SQL: strings.Join(groupColumns, constants.Comma), Vars: groupArgs}})
+		}
+
 		if q.limit != nil {
 			resultDb.Limit(*q.limit)
 		}
@@ -414,14 +672,50 @@ func buildCondition[T any](q *QueryCond[T], opts ...OptionFunc) *gorm.DB {
 			resultDb.Offset(q.offset)
 		}
 	}
+
+	// 设置逻辑删除的过滤条件
+	addLogicDeleteIfNeed[T](q, resultDb, opts)
+
+	// 设置多租户的过滤条件，获取不到租户ID时拒绝执行
+	if err := addTenantIfNeed[T](q, resultDb); err != nil {
+		resultDb.AddError(err)
+	}
+
 	return resultDb
 }
 
-func buildSqlAndArgs[T any](expressions []any, sqlBuilder *strings.Builder, queryArgs []any) []any {
+// checkGlobalUpdate 没有查询条件时拒绝更新、删除全部记录，返回 gorm.ErrMissingWhereClause
+// 逻辑删除、租户等自动添加的条件会绕过 gorm 的检查，需要根据用户设置的条件判断，使用 AllowGlobalUpdate 会话时允许执行
+func checkGlobalUpdate[T any](q *QueryCond[T], resultDb *gorm.DB) bool {
+	if (q != nil && len(q.queryExpressions) > 0) || resultDb.AllowGlobalUpdate {
+		return true
+	}
+	resultDb.AddError(gorm.ErrMissingWhereClause)
+	return false
+}
+
+// buildSqlOption 构建sql语句时的选项
+type buildSqlOption struct {
+	qualified      bool     // 字段名是否需要带上表名
+	db             *gorm.DB // 构建子查询使用的db
+	includeDeleted bool     // 关联表是否包含已经逻辑删除的记录
+}
+
+func buildSqlAndArgs[T any](expressions []any, sqlBuilder *strings.Builder, queryArgs []any, option buildSqlOption) []any {
 	for _, v := range expressions {
 		// 判断是否是columnValue类型
 		switch segment := v.(type) {
 		case *columnPointer:
+			// 包含参数或者依赖数据库类型的函数，执行时由 gorm 生成
+			if f, ok := segment.column.(*Function); ok && !f.isStatic() {
+				sqlBuilder.WriteString("? ")
+				queryArgs = append(queryArgs, f)
+				continue
+			}
+			if option.qualified && !segment.qualified {
+				sqlBuilder.WriteString(getTableColumnName(segment.column) + " ")
+				continue
+			}
 			sqlBuilder.WriteString(segment.getSqlSegment() + " ")
 		case *sqlKeyword:
 			sqlBuilder.WriteString(segment.getSqlSegment() + " ")
@@ -434,6 +728,10 @@ func buildSqlAndArgs[T any](expressions []any, sqlBuilder *strings.Builder, quer
 				sqlBuilder.WriteString("? ")
 				queryArgs = append(queryArgs, segment.value)
 			}
+		case *subQueryValue:
+			// 子查询以 *gorm.DB 作为参数，由 gorm 展开子查询语句并按顺序合并子查询的参数
+			sqlBuilder.WriteString(constants.LeftBracket + "?" + constants.RightBracket + " ")
+			queryArgs = append(queryArgs, segment.query.buildSubQuery(option.db))
 		case *QueryCond[T]:
 			// 当子条件不存在查询表达式时，无需进行递归处理
 			if len(segment.queryExpressions) == 0 {
@@ -441,22 +739,124 @@ func buildSqlAndArgs[T any](expressions []any, sqlBuilder *strings.Builder, quer
 			}
 			sqlBuilder.WriteString(constants.LeftBracket + " ")
 			// 递归处理条件
-			queryArgs = buildSqlAndArgs[T](segment.queryExpressions, sqlBuilder, queryArgs)
+			queryArgs = buildSqlAndArgs[T](segment.queryExpressions, sqlBuilder, queryArgs, option)
 			sqlBuilder.WriteString(constants.RightBracket + " ")
 		}
 	}
 	return queryArgs
 }
 
+// buildSelectExpr 查询字段包含函数参数时，字段和函数都使用 ? 占位，执行时按顺序生成
+func buildSelectExpr(columns []string, args []any) (string, []any) {
+	selectArgs := make([]any, 0, len(columns))
+	for _, column := range columns {
+		if column == "?" && len(args) > 0 {
+			selectArgs = append(selectArgs, args[0])
+			args = args[1:]
+			continue
+		}
+		selectArgs = append(selectArgs, selectColumn(column))
+	}
+	return strings.TrimSuffix(strings.Repeat("?,", len(columns)), ","), selectArgs
+}
+
+// selectColumn 查询字段，和 gorm 处理字段切片的规则一致，实体中的字段使用引号
+type selectColumn string
+
+func (c selectColumn) Build(builder clause.Builder) {
+	if stmt, ok := builder.(*gorm.Statement); ok && stmt.Schema != nil {
+		if field := stmt.Schema.LookUpField(string(c)); field != nil {
+			stmt.WriteQuoted(clause.Column{Name: field.DBName})
+			return
+		}
+	}
+	builder.WriteString(string(c))
+}
+
+// groupByExpr 包含参数的 GROUP BY，gorm 的 GroupBy 不支持参数，合并已经设置的 HAVING 条件
+type groupByExpr struct {
+	expr   clause.Expr
+	having []clause.Expression
+}
+
+func (g groupByExpr) Name() string {
+	return "GROUP BY"
+}
+
+func (g groupByExpr) Build(builder clause.Builder) {
+	g.expr.Build(builder)
+	if len(g.having) > 0 {
+		builder.WriteString(" HAVING ")
+		clause.Where{Exprs: g.having}.Build(builder)
+	}
+}
+
+func (g groupByExpr) MergeClause(c *clause.Clause) {
+	if groupBy, ok := c.Expression.(clause.GroupBy); ok {
+		g.having = append(append([]clause.Expression(nil), groupBy.Having...), g.having...)
+	}
+	c.Expression = g
+}
+
+// addLogicDeleteIfNeed 实体配置了逻辑删除时，自动添加未删除的过滤条件
+func addLogicDeleteIfNeed[T any](q *QueryCond[T], resultDb *gorm.DB, opts []OptionFunc) {
+	ld := getLogicDelete[T]()
+	if ld == nil || getOption(opts).IncludeDeleted || q.isFromSubQuery() {
+		return
+	}
+	columnName := ld.columnName
+	if q != nil && len(q.joins) > 0 {
+		columnName = getTableName(new(T)) + constants.Dot + columnName
+	}
+	resultDb.Where(columnName+" = ?", ld.undeletedValue)
+}
+
+// buildJoinSqlAndArgs 构建连表语句：LEFT JOIN 表 ON 表1.字段1 = 表2.字段2 AND ( 附加条件 )
+// 关联表存在租户字段或者配置了逻辑删除时，同样在 ON 中添加租户和未删除的过滤条件
+func buildJoinSqlAndArgs[T any](join *joinCond[T], option buildSqlOption) (string, []any, error) {
+	var sqlBuilder strings.Builder
+	sqlBuilder.WriteString(join.joinType + " " + join.tableName + " " + constants.On + " ")
+	sqlBuilder.WriteString(getTableColumnName(join.column) + " " + constants.Eq + " " + getTableColumnName(join.joinColumn) + " ")
+	var args []any
+	if join.model != nil {
+		if field := getTenantField(join.model); field != nil {
+			tenantId, ok := globalOption.Tenant.TenantId(option.db.Statement.Context)
+			if !ok {
+				return "", nil, ErrTenantNotFound
+			}
+			sqlBuilder.WriteString(constants.And + " " + join.tableName + constants.Dot + field.DBName + " " + constants.Eq + " ? ")
+			args = append(args, tenantId)
+		}
+		if ld := getModelLogicDelete(reflect.TypeOf(join.model)); ld != nil && !option.includeDeleted {
+			sqlBuilder.WriteString(constants.And + " " + join.tableName + constants.Dot + ld.columnName + " " + constants.Eq + " ? ")
+			args = append(args, ld.undeletedValue)
+		}
+	}
+	if join.onQuery != nil && len(join.onQuery.queryExpressions) > 0 {
+		sqlBuilder.WriteString(constants.And + " " + constants.LeftBracket + " ")
+		option.qualified = true
+		args = buildSqlAndArgs[T](join.onQuery.queryExpressions, &sqlBuilder, args, option)
+		sqlBuilder.WriteString(constants.RightBracket)
+	}
+	return strings.TrimSpace(sqlBuilder.String()), args, nil
+}
+
 func getDb(opts ...OptionFunc) *gorm.DB {
 	option := getOption(opts)
-	// Clauses()目的是为了初始化Db，如果db已经被初始化了,会直接返回db
-	var db = globalDb.Clauses()
+	var db = globalDb
 
 	if option.Db != nil {
-		db = option.Db.Clauses()
+		db = option.Db
 	}
 
+	// 设置上下文
+	if option.Ctx != nil {
+		db = db.WithContext(option.Ctx)
+	}
+
+	// Clauses()目的是为了初始化Db，如果db已经被初始化了,会直接返回db
+	db = db.Clauses()
+
 	// 设置需要忽略的字段
 	setOmitIfNeed(option, db)
 
@@ -0,0 +1,455 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gplus
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"github.com/acmestack/gorm-plus/constants"
+	"gorm.io/gorm"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// ErrInvalidCiphertext 密文格式错误或者密钥不正确，无法解密
+var ErrInvalidCiphertext = errors.New("gplus: invalid ciphertext")
+
+const (
+	encryptTag = "ENCRYPT"
+	maskTag    = "MASK"
+)
+
+// Encryptor 字段加解密，设置了 gplus:"encrypt" 标签的字符串字段插入、更新时加密，查询时解密
+// 等于、不等于、IN 条件的参数同样会被加密，所以需要使用确定性加密：相同的明文加密结果相同
+type Encryptor interface {
+	Encrypt(ctx context.Context, plaintext string) (string, error)
+	Decrypt(ctx context.Context, ciphertext string) (string, error)
+}
+
+// Encrypt 开启字段加密
+func Encrypt(encryptor Encryptor) InitOptionFunc {
+	return func(o *InitOption) {
+		o.Encryptor = encryptor
+	}
+}
+
+// KeyProvider 获取加密密钥，可以根据上下文返回不同的密钥，AES 密钥长度为 16、24 或者 32 字节
+type KeyProvider func(ctx context.Context) ([]byte, error)
+
+// NewAESEncryptor 创建确定性的 AES-GCM 加密器，nonce 由密钥和明文的 HMAC 生成，密文为 base64 编码
+func NewAESEncryptor(keyProvider KeyProvider) Encryptor {
+	return &aesEncryptor{keyProvider: keyProvider}
+}
+
+type aesEncryptor struct {
+	keyProvider KeyProvider
+}
+
+func (e *aesEncryptor) aead(ctx context.Context) (cipher.AEAD, []byte, error) {
+	key, err := e.keyProvider(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, nil, err
+	}
+	return aead, key, nil
+}
+
+func (e *aesEncryptor) Encrypt(ctx context.Context, plaintext string) (string, error) {
+	aead, key, err := e.aead(ctx)
+	if err != nil {
+		return "", err
+	}
+	// 生成 nonce 的密钥与加密密钥分开派生
+	macKey := sha256.Sum256(append([]byte("gplus:nonce:"), key...))
+	mac := hmac.New(sha256.New, macKey[:])
+	mac.Write([]byte(plaintext))
+	nonce := mac.Sum(nil)[:aead.NonceSize()]
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(plaintext), nil)), nil
+}
+
+func (e *aesEncryptor) Decrypt(ctx context.Context, ciphertext string) (string, error) {
+	aead, _, err := e.aead(ctx)
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil || len(data) < aead.NonceSize() {
+		return "", ErrInvalidCiphertext
+	}
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return "", ErrInvalidCiphertext
+	}
+	return string(plaintext), nil
+}
+
+// 内置的脱敏规则，key为规则名称
+var maskRules = sync.Map{}
+
+func init() {
+	RegisterMaskRule("phone", func(value string) string {
+		return maskMiddle(value, 3, 4)
+	})
+	RegisterMaskRule("idcard", func(value string) string {
+		return maskMiddle(value, 3, 4)
+	})
+	RegisterMaskRule("bankcard", func(value string) string {
+		return maskMiddle(value, 0, 4)
+	})
+	RegisterMaskRule("name", func(value string) string {
+		return maskMiddle(value, 1, 0)
+	})
+	RegisterMaskRule("email", func(value string) string {
+		at := strings.LastIndex(value, "@")
+		if at < 0 {
+			return maskMiddle(value, 1, 0)
+		}
+		return maskMiddle(value[:at], 1, 0) + value[at:]
+	})
+	RegisterMaskRule("password", func(value string) string {
+		return "******"
+	})
+}
+
+// RegisterMaskRule 注册脱敏规则，字段通过 gplus:"mask:规则名称" 标签使用，已存在的规则会被覆盖
+// 内置规则：phone、idcard、bankcard、name、email、password
+func RegisterMaskRule(name string, rule func(value string) string) {
+	maskRules.Store(strings.ToLower(name), rule)
+}
+
+// 保留前 prefix 个和后 suffix 个字符，中间的字符替换为 *，长度不足时全部替换
+func maskMiddle(value string, prefix int, suffix int) string {
+	runes := []rune(value)
+	if len(runes) <= prefix+suffix {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:prefix]) + strings.Repeat("*", len(runes)-prefix-suffix) + string(runes[len(runes)-suffix:])
+}
+
+// Mask 按照字段的 gplus:"mask:规则名称" 标签对记录脱敏，分页查询的记录会自动脱敏
+func Mask[T any](entities ...*T) {
+	for _, entity := range entities {
+		maskValue(reflect.ValueOf(entity))
+	}
+}
+
+type secretField struct {
+	index      []int
+	columnName string
+	encrypt    bool
+	mask       string
+}
+
+// 缓存结构体中需要加密或者脱敏的字段，key为结构体类型
+var secretFieldCache sync.Map
+
+// 获取结构体中设置了加密或者脱敏标签的字符串字段
+func getSecretFields(structType reflect.Type) []*secretField {
+	if value, ok := secretFieldCache.Load(structType); ok {
+		return value.([]*secretField)
+	}
+	var fields []*secretField
+	walkTagFields(structType, func(field reflect.StructField, index []int, tagSetting map[string]string) bool {
+		if field.Type.Kind() != reflect.String {
+			return true
+		}
+		sf := &secretField{index: index, columnName: parseColumnName(field)}
+		for key, value := range tagSetting {
+			switch {
+			case key == encryptTag:
+				sf.encrypt = true
+			case key == maskTag:
+				sf.mask = strings.ToLower(value)
+			case strings.HasPrefix(key, maskTag+"="):
+				// 兼容 gplus:"mask=phone" 的写法
+				sf.mask = strings.ToLower(strings.TrimPrefix(key, maskTag+"="))
+			}
+		}
+		if sf.encrypt || sf.mask != "" {
+			fields = append(fields, sf)
+		}
+		return true
+	})
+	secretFieldCache.Store(structType, fields)
+	return fields
+}
+
+// 获取实体需要加密的字段名
+func getEncryptColumns[T any]() map[string]bool {
+	columns := make(map[string]bool)
+	modelType := reflect.TypeOf((*T)(nil)).Elem()
+	if modelType.Kind() != reflect.Struct {
+		return columns
+	}
+	for _, field := range getSecretFields(modelType) {
+		if field.encrypt {
+			columns[field.columnName] = true
+		}
+	}
+	return columns
+}
+
+// 遍历结果中的结构体，支持结构体、结构体指针以及它们的切片
+func walkStruct(value reflect.Value, fn func(structValue reflect.Value) error) error {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if value.IsNil() {
+			return nil
+		}
+		return walkStruct(value.Elem(), fn)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if err := walkStruct(value.Index(i), fn); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		return fn(value)
+	}
+	return nil
+}
+
+// 遍历结构体中需要加密或者脱敏的字段，嵌入的结构体指针为nil时跳过
+func walkSecretFields(structValue reflect.Value, fn func(field *secretField, fieldValue reflect.Value) error) error {
+	for _, field := range getSecretFields(structValue.Type()) {
+		fieldValue, err := structValue.FieldByIndexErr(field.index)
+		if err != nil || !fieldValue.CanSet() {
+			continue
+		}
+		if err = fn(field, fieldValue); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encryptEntitiesIfNeed 插入、更新前加密实体的字段，返回恢复明文的函数，执行语句后需要调用，避免修改调用方的实体
+func encryptEntitiesIfNeed[T any](db *gorm.DB, entities ...*T) (func(), error) {
+	encryptor := globalOption.Encryptor
+	var originals []func()
+	restore := func() {
+		for _, original := range originals {
+			original()
+		}
+	}
+	if encryptor == nil || len(getEncryptColumns[T]()) == 0 {
+		return restore, nil
+	}
+	ctx := db.Statement.Context
+	for _, entity := range entities {
+		err := walkStruct(reflect.ValueOf(entity), func(structValue reflect.Value) error {
+			return walkSecretFields(structValue, func(field *secretField, fieldValue reflect.Value) error {
+				plaintext := fieldValue.String()
+				if !field.encrypt || plaintext == "" {
+					return nil
+				}
+				ciphertext, err := encryptor.Encrypt(ctx, plaintext)
+				if err != nil {
+					return err
+				}
+				fieldValue.SetString(ciphertext)
+				originals = append(originals, func() {
+					fieldValue.SetString(plaintext)
+				})
+				return nil
+			})
+		})
+		if err != nil {
+			restore()
+			return func() {}, err
+		}
+	}
+	return restore, nil
+}
+
+// encryptUpdateMapIfNeed 使用 QueryCond.Set 更新时加密更新的值
+func encryptUpdateMapIfNeed[T any](db *gorm.DB, updateMap map[string]any) error {
+	encryptor := globalOption.Encryptor
+	if encryptor == nil {
+		return nil
+	}
+	for column := range getEncryptColumns[T]() {
+		value, ok := updateMap[column].(string)
+		if !ok || value == "" {
+			continue
+		}
+		ciphertext, err := encryptor.Encrypt(db.Statement.Context, value)
+		if err != nil {
+			return err
+		}
+		updateMap[column] = ciphertext
+	}
+	return nil
+}
+
+// decryptResult 解密查询结果，解密失败时添加错误
+func decryptResult(db *gorm.DB, dest any) {
+	if err := decryptIfNeed(db, dest); err != nil {
+		db.AddError(err)
+	}
+}
+
+// decryptIfNeed 解密查询结果中设置了 gplus:"encrypt" 标签的字段
+func decryptIfNeed(db *gorm.DB, dest any) error {
+	encryptor := globalOption.Encryptor
+	if encryptor == nil || db.Error != nil {
+		return nil
+	}
+	ctx := db.Statement.Context
+	return walkStruct(reflect.ValueOf(dest), func(structValue reflect.Value) error {
+		return walkSecretFields(structValue, func(field *secretField, fieldValue reflect.Value) error {
+			if !field.encrypt || fieldValue.String() == "" {
+				return nil
+			}
+			plaintext, err := encryptor.Decrypt(ctx, fieldValue.String())
+			if err != nil {
+				return err
+			}
+			fieldValue.SetString(plaintext)
+			return nil
+		})
+	})
+}
+
+// maskIfNeed 分页查询的记录自动脱敏，设置了 IgnoreMask 选项时不脱敏
+func maskIfNeed(db *gorm.DB, dest any, opts []OptionFunc) {
+	if db.Error != nil || getOption(opts).IgnoreMask {
+		return
+	}
+	maskValue(reflect.ValueOf(dest))
+}
+
+func maskValue(value reflect.Value) {
+	_ = walkStruct(value, func(structValue reflect.Value) error {
+		return walkSecretFields(structValue, func(field *secretField, fieldValue reflect.Value) error {
+			if field.mask == "" || fieldValue.String() == "" {
+				return nil
+			}
+			if rule, ok := maskRules.Load(field.mask); ok {
+				fieldValue.SetString(rule.(func(string) string)(fieldValue.String()))
+			}
+			return nil
+		})
+	})
+}
+
+// encryptQueryIfNeed 加密等于、不等于、IN 条件中加密字段的参数
+// 需要加密时返回加密后的查询条件副本，不修改原来的查询条件
+func encryptQueryIfNeed[T any](q *QueryCond[T], db *gorm.DB) (*QueryCond[T], error) {
+	encryptor := globalOption.Encryptor
+	if q == nil || encryptor == nil {
+		return q, nil
+	}
+	columns := getEncryptColumns[T]()
+	if len(columns) == 0 {
+		return q, nil
+	}
+	c := q.Clone()
+	tableName := getTableName(new(T))
+	if err := encryptExpressions(db.Statement.Context, encryptor, c.queryExpressions, columns, tableName); err != nil {
+		return q, err
+	}
+	return c, nil
+}
+
+func encryptExpressions(ctx context.Context, encryptor Encryptor, expressions []any, columns map[string]bool, tableName string) error {
+	var column, keyword string
+	for i, expression := range expressions {
+		switch segment := expression.(type) {
+		case *columnPointer:
+			column, keyword = "", ""
+			if isEncryptColumn(segment.column, columns, tableName) {
+				column = getColumnName(segment.column)
+			}
+		case *sqlKeyword:
+			keyword = segment.keyword
+		case *columnValue:
+			if column == "" || !isEncryptKeyword(keyword) {
+				continue
+			}
+			value, err := encryptValue(ctx, encryptor, segment.value)
+			if err != nil {
+				return err
+			}
+			expressions[i] = &columnValue{value: value}
+		case interface{ getExpressions() []any }:
+			if err := encryptExpressions(ctx, encryptor, segment.getExpressions(), columns, tableName); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// 字段是否为当前实体的加密字段，连表查询时其他表的同名字段不加密
+func isEncryptColumn(column any, columns map[string]bool, tableName string) bool {
+	if !columns[getColumnName(column)] {
+		return false
+	}
+	valueOf := reflect.ValueOf(column)
+	if valueOf.Kind() == reflect.Pointer {
+		if table, ok := columnTableCache.Load(valueOf.Pointer()); ok {
+			return table.(string) == tableName
+		}
+	}
+	return true
+}
+
+func isEncryptKeyword(keyword string) bool {
+	switch keyword {
+	case constants.Eq, constants.Ne, constants.In, constants.Not + " " + constants.In:
+		return true
+	}
+	return false
+}
+
+// 加密字符串参数，IN 条件的切片参数逐个加密
+func encryptValue(ctx context.Context, encryptor Encryptor, value any) (any, error) {
+	if plaintext, ok := value.(string); ok {
+		if plaintext == "" {
+			return plaintext, nil
+		}
+		return encryptor.Encrypt(ctx, plaintext)
+	}
+	valueOf := reflect.ValueOf(value)
+	if valueOf.Kind() != reflect.Slice && valueOf.Kind() != reflect.Array {
+		return value, nil
+	}
+	values := make([]any, valueOf.Len())
+	for i := 0; i < valueOf.Len(); i++ {
+		encrypted, err := encryptValue(ctx, encryptor, valueOf.Index(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		values[i] = encrypted
+	}
+	return values, nil
+}
@@ -19,17 +19,53 @@ package gplus
 
 import (
 	"github.com/acmestack/gorm-plus/constants"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"reflect"
+	"strconv"
 	"strings"
 )
 
+// Function sql函数，字面量参数使用 ? 占位，依赖数据库类型的函数在执行时根据语句的数据库类型生成
 type Function struct {
-	funStr string
+	funStr string                               // 函数sql，参数使用 ? 占位
+	args   []any                                // 函数参数
+	build  func(dialect string) (string, []any) // 依赖数据库类型的函数，为 nil 时使用 funStr 和 args
 }
 
+func newFunction(funStr string, args ...any) *Function {
+	return &Function{funStr: funStr, args: args}
+}
+
+// 不包含参数并且不依赖数据库类型的函数，可以直接作为字段名称拼接到sql中
+func (f *Function) isStatic() bool {
+	return f.build == nil && len(f.args) == 0
+}
+
+// Build 实现 gorm 的 clause.Expression，根据执行语句的数据库类型生成函数sql，并按顺序添加参数
+func (f *Function) Build(builder clause.Builder) {
+	funStr, args := f.funStr, f.args
+	if f.build != nil {
+		var dialect string
+		if stmt, ok := builder.(*gorm.Statement); ok && stmt.DB != nil && stmt.DB.Dialector != nil {
+			dialect = stmt.DB.Dialector.Name()
+		}
+		funStr, args = f.build(dialect)
+	}
+	clause.Expr{SQL: funStr, Vars: args}.Build(builder)
+}
+
+// As 函数别名，返回的字符串只包含函数sql，函数包含参数或者依赖数据库类型时参数会丢失，需要使用 Alias
 func (f *Function) As(asName any) string {
 	return f.funStr + " " + constants.As + " " + getColumnName(asName)
 }
 
+// Alias 函数别名，返回的函数保留参数，并且在执行时根据数据库类型生成
+func (f *Function) Alias(asName any) *Function {
+	funStr, args := buildFunArg(f)
+	return newFunction(funStr+" "+constants.As+" "+getColumnName(asName), args...)
+}
+
 func (f *Function) Eq(value int64) (string, int64) {
 	return buildFunStr(f.funStr, constants.Eq, value)
 }
@@ -54,16 +90,43 @@ func (f *Function) Le(value int64) (string, int64) {
 	return buildFunStr(f.funStr, constants.Le, value)
 }
 
+// EqValue 与 Eq 相同，支持任意类型的值，并且保留函数的参数，可以直接传给 Having
+func (f *Function) EqValue(value any) (string, []any) {
+	return buildFunCond(f, constants.Eq, value)
+}
+
+func (f *Function) NeValue(value any) (string, []any) {
+	return buildFunCond(f, constants.Ne, value)
+}
+
+func (f *Function) GtValue(value any) (string, []any) {
+	return buildFunCond(f, constants.Gt, value)
+}
+
+func (f *Function) GeValue(value any) (string, []any) {
+	return buildFunCond(f, constants.Ge, value)
+}
+
+func (f *Function) LtValue(value any) (string, []any) {
+	return buildFunCond(f, constants.Lt, value)
+}
+
+func (f *Function) LeValue(value any) (string, []any) {
+	return buildFunCond(f, constants.Le, value)
+}
+
 func (f *Function) In(values ...any) (string, []any) {
+	funStr, args := buildFunArg(f)
 	// 构建占位符
 	placeholder := buildPlaceholder(values)
-	return f.funStr + " " + constants.In + placeholder.String(), values
+	return funStr + " " + constants.In + placeholder.String(), append(args, values...)
 }
 
 func (f *Function) NotIn(values ...any) (string, []any) {
+	funStr, args := buildFunArg(f)
 	// 构建占位符
 	placeholder := buildPlaceholder(values)
-	return f.funStr + " " + constants.Not + " " + constants.In + placeholder.String(), values
+	return funStr + " " + constants.Not + " " + constants.In + placeholder.String(), append(args, values...)
 }
 
 func (f *Function) Between(start int64, end int64) (string, int64, int64) {
@@ -74,24 +137,247 @@ func (f *Function) NotBetween(start int64, end int64) (string, int64, int64) {
 	return f.funStr + " " + constants.Not + " " + constants.Between + " ? " + constants.And + " ?", start, end
 }
 
+// BetweenValue 与 Between 相同，支持任意类型的值，并且保留函数的参数
+func (f *Function) BetweenValue(start any, end any) (string, []any) {
+	funStr, args := buildFunArg(f)
+	return funStr + " " + constants.Between + " ? " + constants.And + " ?", append(args, start, end)
+}
+
+func (f *Function) NotBetweenValue(start any, end any) (string, []any) {
+	funStr, args := buildFunArg(f)
+	return funStr + " " + constants.Not + " " + constants.Between + " ? " + constants.And + " ?", append(args, start, end)
+}
+
 func Sum(columnName any) *Function {
-	return &Function{funStr: addBracket(constants.SUM, getColumnName(columnName))}
+	return callFunction(constants.SUM, columnName)
 }
 
 func Avg(columnName any) *Function {
-	return &Function{funStr: addBracket(constants.AVG, getColumnName(columnName))}
+	return callFunction(constants.AVG, columnName)
 }
 
 func Max(columnName any) *Function {
-	return &Function{funStr: addBracket(constants.MAX, getColumnName(columnName))}
+	return callFunction(constants.MAX, columnName)
 }
 
 func Min(columnName any) *Function {
-	return &Function{funStr: addBracket(constants.MIN, getColumnName(columnName))}
+	return callFunction(constants.MIN, columnName)
 }
 
 func Count(columnName any) *Function {
-	return &Function{funStr: addBracket(constants.COUNT, getColumnName(columnName))}
+	return callFunction(constants.COUNT, columnName)
+}
+
+// CountDistinct COUNT(DISTINCT 字段)
+func CountDistinct(columnName any) *Function {
+	funStr, args := buildFunArg(columnName)
+	return newFunction(addBracket(constants.COUNT, constants.DISTINCT+" "+funStr), args...)
+}
+
+// Coalesce 返回第一个非空的值 COALESCE(值1, 值2, ...)
+func Coalesce(values ...any) *Function {
+	return callFunction(constants.COALESCE, values...)
+}
+
+// IfNull 为空时返回默认值，MySQL、SQLite 使用 IFNULL，Postgres 使用 COALESCE，SQL Server 使用 ISNULL
+func IfNull(value any, defaultValue any) *Function {
+	funStr, args := joinFunArgs(value, defaultValue)
+	return &Function{build: func(dialect string) (string, []any) {
+		function := constants.IFNULL
+		switch dialect {
+		case "postgres":
+			function = constants.COALESCE
+		case "sqlserver":
+			function = "ISNULL"
+		}
+		return addBracket(function, funStr), args
+	}}
+}
+
+// Concat 拼接字符串，SQLite 使用 || 拼接
+func Concat(values ...any) *Function {
+	funStrs := make([]string, len(values))
+	var args []any
+	for i, value := range values {
+		var valueArgs []any
+		funStrs[i], valueArgs = buildFunArg(value)
+		args = append(args, valueArgs...)
+	}
+	return &Function{build: func(dialect string) (string, []any) {
+		if dialect == "sqlite" {
+			return constants.LeftBracket + strings.Join(funStrs, " || ") + constants.RightBracket, args
+		}
+		return addBracket(constants.CONCAT, strings.Join(funStrs, constants.Comma+" ")), args
+	}}
+}
+
+// Lower 转换为小写
+func Lower(columnName any) *Function {
+	return callFunction(constants.LOWER, columnName)
+}
+
+// Upper 转换为大写
+func Upper(columnName any) *Function {
+	return callFunction(constants.UPPER, columnName)
+}
+
+// Length 字符串长度，SQL Server 使用 LEN
+func Length(columnName any) *Function {
+	funStr, args := buildFunArg(columnName)
+	return &Function{build: func(dialect string) (string, []any) {
+		if dialect == "sqlserver" {
+			return addBracket("LEN", funStr), args
+		}
+		return addBracket(constants.LENGTH, funStr), args
+	}}
+}
+
+// Round 四舍五入保留指定的小数位数
+func Round(columnName any, decimals int) *Function {
+	funStr, args := buildFunArg(columnName)
+	return newFunction(addBracket(constants.ROUND, funStr+constants.Comma+" "+strconv.Itoa(decimals)), args...)
+}
+
+// DateFormat 格式化日期，格式需要使用对应数据库的格式，
+// MySQL 使用 DATE_FORMAT，Postgres 使用 TO_CHAR，SQLite 使用 STRFTIME，SQL Server 使用 FORMAT
+func DateFormat(columnName any, format string) *Function {
+	funStr, args := buildFunArg(columnName)
+	return &Function{build: func(dialect string) (string, []any) {
+		switch dialect {
+		case "postgres":
+			return addBracket("TO_CHAR", funStr+constants.Comma+" ?"), append(args, format)
+		case "sqlite":
+			return addBracket("STRFTIME", "?"+constants.Comma+" "+funStr), append([]any{format}, args...)
+		case "sqlserver":
+			return addBracket("FORMAT", funStr+constants.Comma+" ?"), append(args, format)
+		}
+		return addBracket("DATE_FORMAT", funStr+constants.Comma+" ?"), append(args, format)
+	}}
+}
+
+// DateUnit 日期截断的单位
+type DateUnit string
+
+const (
+	DateUnitYear  DateUnit = "year"
+	DateUnitMonth DateUnit = "month"
+	DateUnitDay   DateUnit = "day"
+	DateUnitHour  DateUnit = "hour"
+)
+
+// 不支持 DATE_TRUNC 的数据库，使用日期格式化实现截断，MySQL 和 SQLite 的格式相同
+var truncFormats = map[DateUnit]string{
+	DateUnitYear:  "%Y-01-01",
+	DateUnitMonth: "%Y-%m-01",
+	DateUnitDay:   "%Y-%m-%d",
+	DateUnitHour:  "%Y-%m-%d %H:00:00",
+}
+
+// DateTrunc 将日期截断到指定的单位，例如按天、按月统计，单位只支持 DateUnit 中定义的常量
+// Postgres 使用 DATE_TRUNC，SQL Server 使用 DATETRUNC，MySQL、SQLite 使用日期格式化实现
+func DateTrunc(unit DateUnit, columnName any) *Function {
+	funStr, args := buildFunArg(columnName)
+	format := DateFormat(columnName, truncFormats[unit])
+	return &Function{build: func(dialect string) (string, []any) {
+		switch dialect {
+		case "postgres":
+			return addBracket("DATE_TRUNC", "?"+constants.Comma+" "+funStr), append([]any{string(unit)}, args...)
+		case "sqlserver":
+			// SQL Server 的单位是关键字，不能使用参数
+			if _, ok := truncFormats[unit]; ok {
+				return addBracket("DATETRUNC", string(unit)+constants.Comma+" "+funStr), args
+			}
+		}
+		return format.build(dialect)
+	}}
+}
+
+// CaseBuilder CASE WHEN 条件表达式构建器
+type CaseBuilder struct {
+	builder strings.Builder
+	args    []any
+}
+
+// Case 开始构建 CASE WHEN 表达式，例如：
+// Case().When(&u.Age, ">=", 18, Literal("adult")).Else(Literal("minor")).End()
+func Case() *CaseBuilder {
+	c := &CaseBuilder{}
+	c.builder.WriteString(constants.CASE)
+	return c
+}
+
+// When 添加 WHEN 字段 操作符 值 THEN 结果，值和结果与函数参数的规则一致
+func (c *CaseBuilder) When(columnName any, op string, value any, then any) *CaseBuilder {
+	c.builder.WriteString(" " + constants.WHEN + " " + c.addArg(columnName) + " " + op)
+	if op != constants.IsNull && op != constants.IsNotNull {
+		c.builder.WriteString(" " + c.addArg(value))
+	}
+	c.builder.WriteString(" " + constants.THEN + " " + c.addArg(then))
+	return c
+}
+
+// Else 添加 ELSE 结果
+func (c *CaseBuilder) Else(value any) *CaseBuilder {
+	c.builder.WriteString(" " + constants.ELSE + " " + c.addArg(value))
+	return c
+}
+
+// End 结束构建，返回可以继续组合的函数
+func (c *CaseBuilder) End() *Function {
+	return newFunction(c.builder.String()+" "+constants.END, c.args...)
+}
+
+func (c *CaseBuilder) addArg(value any) string {
+	funStr, args := buildFunArg(value)
+	c.args = append(c.args, args...)
+	return funStr
+}
+
+// SqlLiteral 函数中的字面量，字符串参数默认作为字段名称，需要作为值时使用 Literal 包装
+type SqlLiteral struct {
+	value any
+}
+
+// Literal 将值作为字面量，使用 ? 占位，例如：Concat(&u.FirstName, Literal(" "), &u.LastName)
+func Literal(value any) SqlLiteral {
+	return SqlLiteral{value: value}
+}
+
+// 构建函数，参数之间使用逗号分隔
+func callFunction(function string, values ...any) *Function {
+	funStr, args := joinFunArgs(values...)
+	return newFunction(addBracket(function, funStr), args...)
+}
+
+func joinFunArgs(values ...any) (string, []any) {
+	funStrs := make([]string, len(values))
+	var args []any
+	for i, value := range values {
+		var valueArgs []any
+		funStrs[i], valueArgs = buildFunArg(value)
+		args = append(args, valueArgs...)
+	}
+	return strings.Join(funStrs, constants.Comma+" "), args
+}
+
+// 构建函数参数，字段指针、字符串作为字段名称，Literal 和其他类型的值使用 ? 占位，
+// 函数包含参数或者依赖数据库类型时使用 ? 占位，执行时由 gorm 调用 Build 生成
+func buildFunArg(value any) (string, []any) {
+	switch v := value.(type) {
+	case *Function:
+		if v.isStatic() {
+			return v.funStr, nil
+		}
+		return "?", []any{v}
+	case SqlLiteral:
+		return "?", []any{v.value}
+	case string:
+		return v, nil
+	}
+	if value != nil && reflect.TypeOf(value).Kind() == reflect.Pointer {
+		return getColumnName(value), nil
+	}
+	return "?", []any{value}
 }
 
 func As(columnName any, asName any) string {
@@ -106,6 +392,11 @@ func buildFunStr(funcStr string, typeStr string, value int64) (string, int64) {
 	return funcStr + " " + typeStr + " ?", value
 }
 
+func buildFunCond(f *Function, typeStr string, value any) (string, []any) {
+	funStr, args := buildFunArg(f)
+	return funStr + " " + typeStr + " ?", append(args, value)
+}
+
 func buildPlaceholder(values []any) strings.Builder {
 	var placeholder strings.Builder
 	placeholder.WriteString(constants.LeftBracket)
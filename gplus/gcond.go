@@ -0,0 +1,272 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gplus
+
+import (
+	"github.com/acmestack/gorm-plus/constants"
+	"unicode"
+)
+
+// gcond 分组条件的语法，优先级从高到低依次为：! 取反、* 并且、| 或者，可以使用括号改变优先级，例如：(A*!B)|C
+//
+//	expr    = and { "|" and }
+//	and     = unary { "*" unary }
+//	unary   = "!" unary | primary
+//	primary = group | "(" expr ")"
+
+type gcondTokenKind int
+
+const (
+	gcondEOF gcondTokenKind = iota
+	gcondGroup
+	gcondAnd
+	gcondOr
+	gcondNot
+	gcondLeftParen
+	gcondRightParen
+)
+
+type gcondToken struct {
+	kind gcondTokenKind
+	text string
+}
+
+// 词法分析，分组名称由字母、数字和下划线组成，忽略空白字符
+func tokenizeGcond(gcond string) ([]gcondToken, error) {
+	var tokens []gcondToken
+	runes := []rune(gcond)
+	for i := 0; i < len(runes); i++ {
+		char := runes[i]
+		switch {
+		case unicode.IsSpace(char):
+		case char == '*':
+			tokens = append(tokens, gcondToken{kind: gcondAnd, text: "*"})
+		case char == '|':
+			tokens = append(tokens, gcondToken{kind: gcondOr, text: "|"})
+		case char == '!':
+			tokens = append(tokens, gcondToken{kind: gcondNot, text: "!"})
+		case char == '(':
+			tokens = append(tokens, gcondToken{kind: gcondLeftParen, text: "("})
+		case char == ')':
+			tokens = append(tokens, gcondToken{kind: gcondRightParen, text: ")"})
+		case isGroupNameChar(char):
+			start := i
+			for i+1 < len(runes) && isGroupNameChar(runes[i+1]) {
+				i++
+			}
+			tokens = append(tokens, gcondToken{kind: gcondGroup, text: string(runes[start : i+1])})
+		default:
+			return nil, ErrInvalidCondition
+		}
+	}
+	return append(tokens, gcondToken{kind: gcondEOF}), nil
+}
+
+func isGroupNameChar(char rune) bool {
+	return unicode.IsLetter(char) || unicode.IsDigit(char) || char == '_'
+}
+
+type gcondNodeKind int
+
+const (
+	gcondNodeGroup gcondNodeKind = iota
+	gcondNodeAnd
+	gcondNodeOr
+	gcondNodeNot
+	gcondNodeParen
+)
+
+// gcondNode 分组条件的语法树节点，括号单独作为节点，保证生成的sql与用户书写的括号一致
+type gcondNode struct {
+	kind     gcondNodeKind
+	name     string
+	children []*gcondNode
+}
+
+// 遍历语法树中所有的分组名称
+func (n *gcondNode) groupNames() []string {
+	if n.kind == gcondNodeGroup {
+		return []string{n.name}
+	}
+	var names []string
+	for _, child := range n.children {
+		names = append(names, child.groupNames()...)
+	}
+	return names
+}
+
+type gcondParser struct {
+	tokens []gcondToken
+	pos    int
+}
+
+// parseGcond 将分组条件解析为语法树，括号不成对时返回 ErrUnbalancedParentheses，其他语法错误返回 ErrInvalidCondition
+func parseGcond(gcond string) (*gcondNode, error) {
+	tokens, err := tokenizeGcond(gcond)
+	if err != nil {
+		return nil, err
+	}
+	p := &gcondParser{tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	switch p.peek().kind {
+	case gcondEOF:
+		return node, nil
+	case gcondRightParen:
+		return nil, ErrUnbalancedParentheses
+	default:
+		return nil, ErrInvalidCondition
+	}
+}
+
+func (p *gcondParser) peek() gcondToken {
+	return p.tokens[p.pos]
+}
+
+func (p *gcondParser) next() gcondToken {
+	token := p.tokens[p.pos]
+	if token.kind != gcondEOF {
+		p.pos++
+	}
+	return token
+}
+
+func (p *gcondParser) parseOr() (*gcondNode, error) {
+	return p.parseBinary(gcondOr, gcondNodeOr, p.parseAnd)
+}
+
+func (p *gcondParser) parseAnd() (*gcondNode, error) {
+	return p.parseBinary(gcondAnd, gcondNodeAnd, p.parseUnary)
+}
+
+func (p *gcondParser) parseBinary(operator gcondTokenKind, kind gcondNodeKind, operand func() (*gcondNode, error)) (*gcondNode, error) {
+	node, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != operator {
+		return node, nil
+	}
+	binary := &gcondNode{kind: kind, children: []*gcondNode{node}}
+	for p.peek().kind == operator {
+		p.next()
+		child, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		binary.children = append(binary.children, child)
+	}
+	return binary, nil
+}
+
+func (p *gcondParser) parseUnary() (*gcondNode, error) {
+	if p.peek().kind == gcondNot {
+		p.next()
+		child, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &gcondNode{kind: gcondNodeNot, children: []*gcondNode{child}}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *gcondParser) parsePrimary() (*gcondNode, error) {
+	token := p.next()
+	switch token.kind {
+	case gcondGroup:
+		return &gcondNode{kind: gcondNodeGroup, name: token.text}, nil
+	case gcondLeftParen:
+		child, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next().kind != gcondRightParen {
+			return nil, ErrUnbalancedParentheses
+		}
+		return &gcondNode{kind: gcondNodeParen, children: []*gcondNode{child}}, nil
+	case gcondEOF:
+		return nil, ErrInvalidCondition
+	case gcondRightParen:
+		return nil, ErrUnbalancedParentheses
+	default:
+		return nil, ErrInvalidCondition
+	}
+}
+
+// lowerGcond 将语法树转换为嵌套的查询条件，op 为当前节点与前面条件的连接符，不存在的分组会被忽略
+func lowerGcond[T any](q *QueryCond[T], node *gcondNode, op string, queryMaps map[string]*QueryCond[T]) {
+	switch node.kind {
+	case gcondNodeGroup:
+		paramQuery, ok := queryMaps[node.name]
+		if !ok || len(paramQuery.queryExpressions) == 0 {
+			return
+		}
+		addConnector(q, op)
+		q.queryExpressions = append(q.queryExpressions, paramQuery.queryExpressions...)
+		q.last = paramQuery.queryExpressions[len(paramQuery.queryExpressions)-1]
+	case gcondNodeAnd, gcondNodeOr:
+		childOp := constants.And
+		if node.kind == gcondNodeOr {
+			childOp = constants.Or
+		}
+		for i, child := range node.children {
+			if i == 0 {
+				lowerGcond(q, child, op, queryMaps)
+				continue
+			}
+			lowerGcond(q, child, childOp, queryMaps)
+		}
+	case gcondNodeParen:
+		nestQuery := &QueryCond[T]{}
+		lowerGcond(nestQuery, node.children[0], "", queryMaps)
+		if len(nestQuery.queryExpressions) == 0 {
+			return
+		}
+		addConnector(q, op)
+		q.queryExpressions = append(q.queryExpressions, nestQuery)
+		q.last = nestQuery
+	case gcondNodeNot:
+		// 取反的条件总是需要括号，!(A|B) 不需要重复添加括号
+		child := node.children[0]
+		if child.kind == gcondNodeParen {
+			child = child.children[0]
+		}
+		nestQuery := &QueryCond[T]{}
+		lowerGcond(nestQuery, child, "", queryMaps)
+		if len(nestQuery.queryExpressions) == 0 {
+			return
+		}
+		addConnector(q, op)
+		q.Not(func(not *QueryCond[T]) {
+			not.queryExpressions = nestQuery.queryExpressions
+			not.last = nestQuery.last
+		})
+	}
+}
+
+func addConnector[T any](q *QueryCond[T], op string) {
+	switch op {
+	case constants.And:
+		q.And()
+	case constants.Or:
+		q.Or()
+	}
+}
@@ -0,0 +1,198 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gplus
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/acmestack/gorm-plus/constants"
+	"gorm.io/gorm"
+	"reflect"
+)
+
+// Iterator 查询结果迭代器，基于 gorm 的 Rows() 逐行读取，使用完成后需要调用 Close 释放连接
+// 用法与 sql.Rows 保持一致：
+//
+//	for iter.Next() {
+//		user := iter.Value()
+//	}
+//	err := iter.Err()
+type Iterator[T any] struct {
+	db    *gorm.DB
+	rows  *sql.Rows
+	value *T
+	err   error
+}
+
+// Next 读取下一行记录，没有更多记录或者发生错误时返回false，并自动关闭
+func (it *Iterator[T]) Next() bool {
+	if it.rows == nil || it.err != nil {
+		return false
+	}
+	if !it.rows.Next() {
+		it.err = it.rows.Err()
+		it.Close()
+		return false
+	}
+	value := new(T)
+	if err := it.db.ScanRows(it.rows, value); err != nil {
+		it.err = err
+		it.Close()
+		return false
+	}
+	if err := decryptIfNeed(it.db, value); err != nil {
+		it.err = err
+		it.Close()
+		return false
+	}
+	it.value = value
+	return true
+}
+
+// Value 获取当前行记录
+func (it *Iterator[T]) Value() *T {
+	return it.value
+}
+
+// Err 获取迭代过程中发生的错误
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// Close 关闭迭代器，可以重复调用
+func (it *Iterator[T]) Close() error {
+	if it.rows == nil {
+		return nil
+	}
+	err := it.rows.Close()
+	it.rows = nil
+	return err
+}
+
+// All 返回 Go 迭代器风格的遍历函数，Go 1.23 及以上版本可以直接使用 for range 遍历，遍历结束后自动关闭
+func (it *Iterator[T]) All() func(yield func(*T) bool) {
+	return func(yield func(*T) bool) {
+		defer it.Close()
+		for it.Next() {
+			if !yield(it.Value()) {
+				return
+			}
+		}
+	}
+}
+
+// SelectIter 根据条件查询，返回逐行读取的迭代器，适合大结果集的查询
+func SelectIter[T any](q *QueryCond[T], opts ...OptionFunc) (*Iterator[T], *gorm.DB) {
+	resultDb := buildCondition(q, opts...)
+	iter := &Iterator[T]{db: resultDb}
+	if resultDb.Error != nil {
+		iter.err = resultDb.Error
+		return iter, resultDb
+	}
+	rows, err := resultDb.Rows()
+	if err != nil {
+		iter.err = err
+		return iter, resultDb
+	}
+	iter.rows = rows
+	return iter, resultDb
+}
+
+// SelectEach 根据条件查询，逐行回调处理，回调返回错误时停止遍历
+func SelectEach[T any](q *QueryCond[T], fn func(*T) error, opts ...OptionFunc) *gorm.DB {
+	iter, resultDb := SelectIter(q, opts...)
+	defer iter.Close()
+	for iter.Next() {
+		if err := fn(iter.Value()); err != nil {
+			resultDb.AddError(err)
+			return resultDb
+		}
+	}
+	if err := iter.Err(); err != nil && !errors.Is(resultDb.Error, err) {
+		resultDb.AddError(err)
+	}
+	return resultDb
+}
+
+// SelectInChunks 根据主键分批查询，每批按主键升序，下一批从上一批最后一条记录的主键开始（keyset 分页），
+// 内存占用只与 chunkSize 有关，适合全表扫描等场景，回调返回错误时停止查询
+// Tips: 主键需要是可比较的类型，查询条件中不要设置排序和 Limit
+func SelectInChunks[T any](q *QueryCond[T], chunkSize int, fn func([]*T) error, opts ...OptionFunc) *gorm.DB {
+	if chunkSize <= 0 {
+		chunkSize = defaultBatchSize
+	}
+	column := getPkColumnName[T]()
+	if q != nil && len(q.joins) > 0 {
+		column = getTableName(new(T)) + constants.Dot + column
+	}
+	// 指定了查询字段时需要包含主键，否则无法获取下一批的起始位置
+	if q != nil && len(q.selectColumns) > 0 {
+		selectColumns, _ := buildColumns(q.selectColumns, len(q.joins) > 0)
+		if !containsString(selectColumns, "*") && !containsString(selectColumns, column) && !containsString(selectColumns, getPkColumnName[T]()) {
+			q = q.Clone()
+			q.selectColumns = append(q.selectColumns, column)
+		}
+	}
+
+	var lastValue any
+	for {
+		resultDb := buildCondition(q, opts...)
+		if lastValue != nil {
+			resultDb.Where(fmt.Sprintf("%v > ?", column), lastValue)
+		}
+		var results []*T
+		resultDb.Order(column).Limit(chunkSize).Find(&results)
+		decryptResult(resultDb, results)
+		if resultDb.Error != nil || len(results) == 0 {
+			return resultDb
+		}
+		if err := fn(results); err != nil {
+			resultDb.AddError(err)
+			return resultDb
+		}
+		if len(results) < chunkSize {
+			return resultDb
+		}
+		value, err := getPkValue(resultDb, results[len(results)-1])
+		if err != nil {
+			resultDb.AddError(err)
+			return resultDb
+		}
+		// 主键没有递增时继续查询会重复获取同一批记录
+		if reflect.ValueOf(value).IsZero() || (lastValue != nil && reflect.DeepEqual(value, lastValue)) {
+			resultDb.AddError(fmt.Errorf("gplus: primary key %v does not advance, last value: %v", column, value))
+			return resultDb
+		}
+		lastValue = value
+	}
+}
+
+// 获取实体的主键值
+func getPkValue[T any](db *gorm.DB, entity *T) (any, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(entity); err != nil {
+		return nil, err
+	}
+	field := stmt.Schema.LookUpField(getPkColumnName[T]())
+	if field == nil {
+		return nil, fmt.Errorf("gplus: primary key %v not found", getPkColumnName[T]())
+	}
+	value, _ := field.ValueOf(db.Statement.Context, reflect.ValueOf(entity).Elem())
+	return value, nil
+}
@@ -0,0 +1,232 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gplus
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/acmestack/gorm-plus/constants"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// JSONFilter JSON格式的过滤条件，field、op、value 为单个条件，操作符与url参数的操作符一致，
+// and、or 为嵌套条件，同一个节点中的单个条件、and 条件和 or 条件之间使用 AND 连接
+// 例如：{"or":[{"field":"username","op":"~=","value":"zhang"},{"and":[{"field":"age","op":"^=","value":[18,30]}]}]}
+type JSONFilter struct {
+	And   []*JSONFilter `json:"and"`
+	Or    []*JSONFilter `json:"or"`
+	Field string        `json:"field"`
+	Op    string        `json:"op"`
+	Value any           `json:"value"`
+}
+
+// JSONQuery JSON格式的查询条件，排序字段以 - 开头表示降序
+type JSONQuery struct {
+	JSONFilter
+	Sort   []string `json:"sort"`
+	Select []string `json:"select"`
+	Omit   []string `json:"omit"`
+}
+
+// BuildQueryFromJSON 根据JSON构建查询条件，与 BuildQuery 使用相同的操作符、类型转换以及排序、查询字段的处理
+// 过滤、排序、select 和 omit 的字段必须是实体中的字段，否则返回 ErrUnknownColumn
+func BuildQueryFromJSON[T any](data []byte) (*QueryCond[T], error) {
+	return BuildQueryFromJSONWithPolicy[T](data, nil)
+}
+
+// BuildQueryFromJSONWithPolicy 根据策略校验JSON后构建查询条件，参数不满足策略时返回 *QueryParamError
+func BuildQueryFromJSONWithPolicy[T any](data []byte, policy *QueryPolicy) (*QueryCond[T], error) {
+	var jsonQuery JSONQuery
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&jsonQuery); err != nil {
+		return nil, err
+	}
+
+	columnTypeMap := getColumnTypeMap[T]()
+	if err := checkJSONColumns(&jsonQuery, columnTypeMap); err != nil {
+		return nil, err
+	}
+	if policy != nil {
+		if err := policy.checkJSON(&jsonQuery); err != nil {
+			return nil, err
+		}
+	}
+
+	query, _ := NewQuery[T]()
+	for _, column := range jsonQuery.Sort {
+		if strings.HasPrefix(column, "-") {
+			query.OrderByDesc(strings.TrimLeft(column, "-"))
+		} else {
+			query.OrderByAsc(column)
+		}
+	}
+	for _, column := range jsonQuery.Select {
+		query.Select(column)
+	}
+	for _, column := range jsonQuery.Omit {
+		query.Omit(column)
+	}
+
+	if err := lowerJSONFilter(query, &jsonQuery.JSONFilter, "", columnTypeMap); err != nil {
+		return nil, err
+	}
+	return query, nil
+}
+
+// 校验排序、select 和 omit 的字段是否为实体中的字段，过滤条件的字段在转换时校验
+func checkJSONColumns(jsonQuery *JSONQuery, columnTypeMap map[string]reflect.Type) error {
+	for _, column := range jsonQuery.Sort {
+		column = strings.TrimLeft(column, "-")
+		if _, ok := columnTypeMap[column]; !ok {
+			return &QueryParamError{Param: "sort", Column: column, Err: ErrUnknownColumn}
+		}
+	}
+	for _, column := range jsonQuery.Select {
+		if _, ok := columnTypeMap[column]; !ok {
+			return &QueryParamError{Param: "select", Column: column, Err: ErrUnknownColumn}
+		}
+	}
+	for _, column := range jsonQuery.Omit {
+		if _, ok := columnTypeMap[column]; !ok {
+			return &QueryParamError{Param: "omit", Column: column, Err: ErrUnknownColumn}
+		}
+	}
+	return nil
+}
+
+// lowerJSONFilter 将JSON条件转换为查询条件，op 为当前节点与前面条件的连接符
+func lowerJSONFilter[T any](q *QueryCond[T], filter *JSONFilter, op string, columnTypeMap map[string]reflect.Type) error {
+	if filter.Field != "" || filter.Op != "" {
+		if err := addJSONCondition(q, filter, op, columnTypeMap); err != nil {
+			return err
+		}
+		op = constants.And
+	}
+
+	for _, child := range filter.And {
+		if err := lowerJSONChild(q, child, op, columnTypeMap); err != nil {
+			return err
+		}
+		op = constants.And
+	}
+
+	if len(filter.Or) == 0 {
+		return nil
+	}
+	// 只有 or 条件时直接拼接，否则 or 条件需要加上括号
+	if op == "" {
+		for i, child := range filter.Or {
+			if i > 0 {
+				op = constants.Or
+			}
+			if err := lowerJSONChild(q, child, op, columnTypeMap); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return lowerJSONChild(q, &JSONFilter{Or: filter.Or}, op, columnTypeMap)
+}
+
+// 嵌套的条件节点需要加上括号，单个条件直接拼接
+func lowerJSONChild[T any](q *QueryCond[T], child *JSONFilter, op string, columnTypeMap map[string]reflect.Type) error {
+	if len(child.And) == 0 && len(child.Or) == 0 {
+		return lowerJSONFilter(q, child, op, columnTypeMap)
+	}
+	nestQuery := &QueryCond[T]{}
+	if err := lowerJSONFilter(nestQuery, child, "", columnTypeMap); err != nil {
+		return err
+	}
+	if len(nestQuery.queryExpressions) == 0 {
+		return nil
+	}
+	addConnector(q, op)
+	q.queryExpressions = append(q.queryExpressions, nestQuery)
+	q.last = nestQuery
+	return nil
+}
+
+func addJSONCondition[T any](q *QueryCond[T], filter *JSONFilter, op string, columnTypeMap map[string]reflect.Type) error {
+	builder, ok := builders[filter.Op]
+	if !ok || filter.Field == "" {
+		return &QueryParamError{Param: "json", Column: filter.Field, Op: filter.Op, Err: ErrInvalidCondition}
+	}
+	if _, ok = columnTypeMap[filter.Field]; !ok {
+		return &QueryParamError{Param: "json", Column: filter.Field, Op: filter.Op, Err: ErrUnknownColumn}
+	}
+	value, err := jsonValue(filter.Value, filter.Op)
+	if err != nil {
+		return &QueryParamError{Param: "json", Column: filter.Field, Op: filter.Op, Err: ErrInvalidValue}
+	}
+
+	query := &QueryCond[any]{}
+	query.columnTypeMap = columnTypeMap
+	builder(query, filter.Field, value)
+	if len(query.queryExpressions) == 0 {
+		return nil
+	}
+	addConnector(q, op)
+	q.queryExpressions = append(q.queryExpressions, query.queryExpressions...)
+	q.last = query.queryExpressions[len(query.queryExpressions)-1]
+	return nil
+}
+
+// 将JSON的值转换为条件的值，数组只能用于 in 和 between 条件，数组中的每个元素作为单独的值，不再使用逗号拼接
+func jsonValue(value any, op string) (any, error) {
+	values, ok := value.([]any)
+	if !ok {
+		return jsonValueString(value)
+	}
+	switch op {
+	case "?=", "!?=":
+	case "^=", "!^=":
+		if len(values) != 2 {
+			return nil, fmt.Errorf("between value %v should have two elements", value)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported array value %v for %s", value, op)
+	}
+	result := make([]any, len(values))
+	for i, item := range values {
+		s, err := jsonValueString(item)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = s
+	}
+	return result, nil
+}
+
+// 将JSON的值转换为与url参数一致的字符串，null 转换为 "null"
+func jsonValueString(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "null", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	return "", fmt.Errorf("unsupported value %v", value)
+}
@@ -0,0 +1,116 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gplus
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"reflect"
+	"sync"
+)
+
+// ErrLogicDeleteNotConfigured 实体没有配置逻辑删除字段
+var ErrLogicDeleteNotConfigured = errors.New("gplus: logic delete is not configured")
+
+const (
+	logicDeleteTag    = "LOGICDELETE"
+	deletedValueTag   = "DELETED"
+	undeletedValueTag = "UNDELETED"
+)
+
+type logicDelete struct {
+	columnName     string
+	deletedValue   any
+	undeletedValue any
+}
+
+// 缓存实体的逻辑删除配置，key为实体类型，value为逻辑删除配置，没有配置时value为nil
+var logicDeleteCache sync.Map
+
+// RegisterLogicDelete 注册实体的逻辑删除字段，以及已删除和未删除对应的值
+// 也可以直接在字段上设置标签：gplus:"logicDelete;deleted:1;undeleted:0"
+func RegisterLogicDelete[T any](column any, deletedValue any, undeletedValue any) {
+	modelTypeStr := reflect.TypeOf((*T)(nil)).Elem().String()
+	logicDeleteCache.Store(modelTypeStr, &logicDelete{
+		columnName:     getColumnName(column),
+		deletedValue:   deletedValue,
+		undeletedValue: undeletedValue,
+	})
+}
+
+// 获取实体的逻辑删除配置，优先使用注册的配置，没有注册则解析字段标签
+func getLogicDelete[T any]() *logicDelete {
+	return getModelLogicDelete(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+// 根据实体类型获取逻辑删除配置，连表查询时用于获取关联表的配置
+func getModelLogicDelete(modelType reflect.Type) *logicDelete {
+	for modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+	modelTypeStr := modelType.String()
+	if value, ok := logicDeleteCache.Load(modelTypeStr); ok {
+		return value.(*logicDelete)
+	}
+	var ld *logicDelete
+	if modelType.Kind() == reflect.Struct {
+		if field, tagSetting, ok := lookUpTagField(modelType, logicDeleteTag); ok {
+			columnName := parseColumnName(field)
+			// 标签中的值都是字符串，需要根据字段类型转换，默认已删除为1，未删除为0
+			columnTypeMap := map[string]reflect.Type{columnName: field.Type}
+			deletedValue, undeletedValue := "1", "0"
+			if value, isOk := tagSetting[deletedValueTag]; isOk {
+				deletedValue = value
+			}
+			if value, isOk := tagSetting[undeletedValueTag]; isOk {
+				undeletedValue = value
+			}
+			ld = &logicDelete{
+				columnName:     columnName,
+				deletedValue:   convert(columnTypeMap, columnName, deletedValue),
+				undeletedValue: convert(columnTypeMap, columnName, undeletedValue),
+			}
+		}
+	}
+	logicDeleteCache.Store(modelTypeStr, ld)
+	return ld
+}
+
+// Restore 根据条件恢复逻辑删除的记录
+func Restore[T any](q *QueryCond[T], opts ...OptionFunc) *gorm.DB {
+	ld := getLogicDelete[T]()
+	if ld == nil {
+		db := getDb(opts...)
+		db.AddError(ErrLogicDeleteNotConfigured)
+		return db
+	}
+	opts = append(opts[:len(opts):len(opts)], IncludeDeleted())
+	resultDb := buildCondition[T](q, opts...)
+	if !checkCteIfNeed(q, resultDb) || !checkGlobalUpdate(q, resultDb) {
+		return resultDb
+	}
+	resultDb.Where(ld.columnName+" = ?", ld.deletedValue).Update(ld.columnName, ld.undeletedValue)
+	return resultDb
+}
+
+// RestoreById 根据 ID 恢复逻辑删除的记录
+func RestoreById[T any](id any, opts ...OptionFunc) *gorm.DB {
+	q, _ := NewQuery[T]()
+	q.Eq(getPkColumnName[T](), id)
+	return Restore[T](q, opts...)
+}
@@ -0,0 +1,286 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gplus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+const fillTag = "FILL"
+
+// ErrFillValueType 填充的值无法赋值给字段，只支持类型一致、可以直接赋值以及数值类型之间的转换
+var ErrFillValueType = errors.New("gplus: fill value type mismatch")
+
+// FieldFill 字段的填充时机，通过标签设置：gplus:"fill:insert"、gplus:"fill:update"、gplus:"fill:insertUpdate"
+type FieldFill int
+
+const (
+	FillInsert       FieldFill = 1 << iota // 插入时填充
+	FillUpdate                             // 更新时填充
+	FillInsertUpdate = FillInsert | FillUpdate
+)
+
+// MetaObjectHandler 字段自动填充处理器，通过 Init 的 FillHandler 选项注册
+// 插入和更新时调用，通过 MetaObject 给设置了 fill 标签的字段填充值，例如：创建人、更新人
+type MetaObjectHandler interface {
+	InsertFill(ctx context.Context, meta *MetaObject)
+	UpdateFill(ctx context.Context, meta *MetaObject)
+}
+
+// FillHandler 注册字段自动填充处理器
+func FillHandler(handler MetaObjectHandler) InitOptionFunc {
+	return func(o *InitOption) {
+		o.FillHandler = handler
+	}
+}
+
+type fillField struct {
+	name       string
+	columnName string
+	index      []int
+	fieldType  reflect.Type
+	fill       FieldFill
+}
+
+// 缓存实体需要填充的字段，key为实体类型
+var fillFieldCache sync.Map
+
+// MetaObject 需要填充的实体，使用 QueryCond.Set 更新时为更新的字段
+type MetaObject struct {
+	entity    reflect.Value
+	updateMap map[string]any
+	fields    map[string]*fillField
+	overwrite bool  // 更新实体时覆盖已经设置的值
+	err       error // 填充的值类型不匹配时的错误，填充完成后返回给调用方，拒绝执行语句
+}
+
+// Entity 获取填充的实体，使用 QueryCond.Set 更新时返回nil
+func (m *MetaObject) Entity() any {
+	if !m.entity.IsValid() {
+		return nil
+	}
+	return m.entity.Addr().Interface()
+}
+
+// UpdateMap 获取 QueryCond.Set 更新的字段，key为字段名，填充实体时返回nil
+func (m *MetaObject) UpdateMap() map[string]any {
+	return m.updateMap
+}
+
+// HasField 当前操作是否需要填充该字段，fieldName为结构体的字段名称，例如：CreatedBy
+func (m *MetaObject) HasField(fieldName string) bool {
+	_, ok := m.fields[fieldName]
+	return ok
+}
+
+// GetValue 获取字段的值，fieldName为结构体的字段名称，字段不需要填充或者没有设置时返回nil
+func (m *MetaObject) GetValue(fieldName string) any {
+	field, ok := m.fields[fieldName]
+	if !ok {
+		return nil
+	}
+	if m.updateMap != nil {
+		return m.updateMap[field.columnName]
+	}
+	if fieldValue, isOk := m.fieldValue(field); isOk {
+		return fieldValue.Interface()
+	}
+	return nil
+}
+
+// SetValue 填充字段的值，fieldName为结构体的字段名称，只填充当前操作需要填充的字段
+// 插入时已经设置了值(非零值)的字段不会被覆盖；根据实体更新时实体通常是查询出来的记录，字段的值会被覆盖；
+// 使用 QueryCond.Set 更新时显式设置的字段不会被覆盖
+// 值的类型与字段不匹配时返回 ErrFillValueType，并且拒绝执行插入、更新语句
+func (m *MetaObject) SetValue(fieldName string, value any) error {
+	return m.setValue(fieldName, value, m.overwrite)
+}
+
+// StrictSetValue 填充字段的值，总是覆盖已经设置的值，包括 QueryCond.Set 设置的字段
+func (m *MetaObject) StrictSetValue(fieldName string, value any) error {
+	return m.setValue(fieldName, value, true)
+}
+
+func (m *MetaObject) setValue(fieldName string, value any, overwrite bool) error {
+	field, ok := m.fields[fieldName]
+	if !ok || value == nil {
+		return nil
+	}
+	converted, ok := convertFillValue(reflect.ValueOf(value), field.fieldType)
+	if !ok {
+		err := fmt.Errorf("%w: field %s is %v, value is %T", ErrFillValueType, fieldName, field.fieldType, value)
+		if m.err == nil {
+			m.err = err
+		}
+		return err
+	}
+	if m.updateMap != nil {
+		if _, exists := m.updateMap[field.columnName]; overwrite || !exists {
+			m.updateMap[field.columnName] = converted.Interface()
+		}
+		return nil
+	}
+	fieldValue, isOk := m.fieldValue(field)
+	if isOk && (overwrite || fieldValue.IsZero()) {
+		fieldValue.Set(converted)
+	}
+	return nil
+}
+
+func (m *MetaObject) fieldValue(field *fillField) (reflect.Value, bool) {
+	// 嵌入的结构体指针为nil时，无法填充
+	fieldValue, err := m.entity.FieldByIndexErr(field.index)
+	if err != nil {
+		return reflect.Value{}, false
+	}
+	return fieldValue, fieldValue.CanSet()
+}
+
+// 将填充的值转换为字段的类型，只允许直接赋值和数值类型之间的转换，字段是指针时自动创建
+// 不使用 ConvertibleTo，避免整数被转换为对应字符的字符串，例如 42 转换为 "*"
+func convertFillValue(value reflect.Value, fieldType reflect.Type) (reflect.Value, bool) {
+	switch {
+	case value.Type().AssignableTo(fieldType):
+		converted := reflect.New(fieldType).Elem()
+		converted.Set(value)
+		return converted, true
+	case isNumberKind(value.Kind()) && isNumberKind(fieldType.Kind()):
+		return value.Convert(fieldType), true
+	case fieldType.Kind() == reflect.Pointer:
+		elem, ok := convertFillValue(value, fieldType.Elem())
+		if !ok {
+			return reflect.Value{}, false
+		}
+		pointer := reflect.New(fieldType.Elem())
+		pointer.Elem().Set(elem)
+		return pointer, true
+	}
+	return reflect.Value{}, false
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// 获取实体指定填充时机的字段，key为结构体的字段名称
+func getFillFields[T any](fill FieldFill) map[string]*fillField {
+	modelType := reflect.TypeOf((*T)(nil)).Elem()
+	var fields []*fillField
+	if value, ok := fillFieldCache.Load(modelType); ok {
+		fields = value.([]*fillField)
+	} else {
+		if modelType.Kind() == reflect.Struct {
+			fields = lookUpFillFields(modelType)
+		}
+		fillFieldCache.Store(modelType, fields)
+	}
+	result := make(map[string]*fillField)
+	for _, field := range fields {
+		if field.fill&fill != 0 {
+			result[field.name] = field
+		}
+	}
+	return result
+}
+
+// 查找设置了fill标签的字段
+func lookUpFillFields(modelType reflect.Type) []*fillField {
+	var fields []*fillField
+	walkTagFields(modelType, func(field reflect.StructField, index []int, tagSetting map[string]string) bool {
+		value, ok := tagSetting[fillTag]
+		if !ok {
+			return true
+		}
+		var fill FieldFill
+		switch strings.ToLower(value) {
+		case "insert":
+			fill = FillInsert
+		case "update":
+			fill = FillUpdate
+		case "insertupdate", "insert_update":
+			fill = FillInsertUpdate
+		default:
+			return true
+		}
+		fields = append(fields, &fillField{name: field.Name, columnName: parseColumnName(field), index: index, fieldType: field.Type, fill: fill})
+		return true
+	})
+	return fields
+}
+
+// fillInsertIfNeed 插入记录时调用填充处理器填充实体，填充的值类型不匹配时返回 ErrFillValueType
+func fillInsertIfNeed[T any](db *gorm.DB, entities ...*T) error {
+	handler := globalOption.FillHandler
+	if handler == nil {
+		return nil
+	}
+	fields := getFillFields[T](FillInsert)
+	if len(fields) == 0 {
+		return nil
+	}
+	for _, entity := range entities {
+		meta := &MetaObject{entity: reflect.ValueOf(entity).Elem(), fields: fields}
+		handler.InsertFill(db.Statement.Context, meta)
+		if meta.err != nil {
+			return meta.err
+		}
+	}
+	return nil
+}
+
+// fillUpdateIfNeed 根据实体更新记录时调用填充处理器填充实体
+func fillUpdateIfNeed[T any](db *gorm.DB, entity *T) error {
+	handler := globalOption.FillHandler
+	if handler == nil {
+		return nil
+	}
+	fields := getFillFields[T](FillUpdate)
+	if len(fields) == 0 {
+		return nil
+	}
+	meta := &MetaObject{entity: reflect.ValueOf(entity).Elem(), fields: fields, overwrite: true}
+	handler.UpdateFill(db.Statement.Context, meta)
+	return meta.err
+}
+
+// fillUpdateMapIfNeed 使用 QueryCond.Set 更新记录时调用填充处理器填充更新的字段
+func fillUpdateMapIfNeed[T any](db *gorm.DB, updateMap map[string]any) error {
+	handler := globalOption.FillHandler
+	if handler == nil {
+		return nil
+	}
+	fields := getFillFields[T](FillUpdate)
+	if len(fields) == 0 {
+		return nil
+	}
+	meta := &MetaObject{updateMap: updateMap, fields: fields}
+	handler.UpdateFill(db.Statement.Context, meta)
+	return meta.err
+}
@@ -17,17 +17,40 @@
 
 package gplus
 
-import "gorm.io/gorm"
+import (
+	"context"
+	"gorm.io/gorm"
+)
 
 type Option struct {
-	Db          *gorm.DB
-	Selects     []any
-	Omits       []any
-	IgnoreTotal bool
+	Db             *gorm.DB
+	Ctx            context.Context
+	Selects        []any
+	Omits          []any
+	IgnoreTotal    bool
+	IncludeDeleted bool
+	IgnoreMask     bool
 }
 
 type OptionFunc func(*Option)
 
+// InitOption 初始化gplus时的全局配置
+type InitOption struct {
+	Tenant      *TenantPlugin
+	FillHandler MetaObjectHandler
+	Audit       *AuditPlugin
+	Encryptor   Encryptor
+}
+
+type InitOptionFunc func(*InitOption)
+
+// Tenant 开启多租户插件
+func Tenant(tenant *TenantPlugin) InitOptionFunc {
+	return func(o *InitOption) {
+		o.Tenant = tenant
+	}
+}
+
 // Db 使用传入的Db对象
 func Db(db *gorm.DB) OptionFunc {
 	return func(o *Option) {
@@ -35,6 +58,13 @@ func Db(db *gorm.DB) OptionFunc {
 	}
 }
 
+// Ctx 使用传入的上下文，超时、取消、链路追踪以及租户等信息会通过上下文传递给数据库驱动
+func Ctx(ctx context.Context) OptionFunc {
+	return func(o *Option) {
+		o.Ctx = ctx
+	}
+}
+
 // Session 创建回话
 func Session(session *gorm.Session) OptionFunc {
 	return func(o *Option) {
@@ -62,3 +92,17 @@ func IgnoreTotal() OptionFunc {
 		o.IgnoreTotal = true
 	}
 }
+
+// IgnoreMask 分页查询的记录不进行脱敏
+func IgnoreMask() OptionFunc {
+	return func(o *Option) {
+		o.IgnoreMask = true
+	}
+}
+
+// IncludeDeleted 查询、更新时包含已经逻辑删除的记录
+func IncludeDeleted() OptionFunc {
+	return func(o *Option) {
+		o.IncludeDeleted = true
+	}
+}
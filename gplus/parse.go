@@ -0,0 +1,165 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gplus
+
+import (
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 校验时间类型参数时支持的格式
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"}
+
+// ParseQuery 与 BuildQuery 一样根据url参数构建查询条件，但是会先校验参数，
+// 参数有误时返回 QueryParamErrors，包含所有出错的参数，不会忽略错误的条件
+func ParseQuery[T any](queryParams url.Values) (*QueryCond[T], error) {
+	if errs := checkQueryParams[T](queryParams); len(errs) > 0 {
+		return nil, errs
+	}
+	return BuildQuery[T](queryParams), nil
+}
+
+func checkQueryParams[T any](queryParams url.Values) QueryParamErrors {
+	var errs QueryParamErrors
+	columnTypeMap := getColumnTypeMap[T]()
+
+	groups := make(map[string]bool)
+	for _, value := range queryParams["q"] {
+		op := getCurrentOp(value)
+		if op == "" {
+			errs = append(errs, &QueryParamError{Param: "q", Value: value, Err: ErrInvalidCondition})
+			continue
+		}
+		params := strings.SplitN(value, op, 2)
+		names := strings.Split(params[0], ".")
+		if len(names) > 2 {
+			errs = append(errs, &QueryParamError{Param: "q", Op: op, Value: value, Err: ErrInvalidCondition})
+			continue
+		}
+		if len(names) == 2 {
+			groups[names[0]] = true
+		}
+		column := names[len(names)-1]
+		columnType, ok := columnTypeMap[column]
+		if !ok {
+			errs = append(errs, &QueryParamError{Param: "q", Column: column, Op: op, Err: ErrUnknownColumn})
+			continue
+		}
+		if !checkConditionValue(columnType, op, params[1]) {
+			errs = append(errs, &QueryParamError{Param: "q", Column: column, Op: op, Value: params[1], Err: ErrInvalidValue})
+		}
+	}
+
+	for _, param := range []string{"sort", "select", "omit"} {
+		values := queryParams[param]
+		if len(values) == 0 {
+			continue
+		}
+		for _, column := range strings.Split(values[len(values)-1], ",") {
+			if param == "sort" {
+				column = strings.TrimLeft(column, "-")
+			}
+			if _, ok := columnTypeMap[column]; !ok {
+				errs = append(errs, &QueryParamError{Param: param, Column: column, Err: ErrUnknownColumn})
+			}
+		}
+	}
+
+	if gcond := queryParams["gcond"]; len(gcond) > 0 {
+		errs = append(errs, checkGroupCondition(gcond[0], groups)...)
+	}
+	return errs
+}
+
+// 校验 gcond 的语法，以及引用的分组是否存在
+func checkGroupCondition(gcond string, groups map[string]bool) QueryParamErrors {
+	node, err := parseGcond(gcond)
+	if err != nil {
+		return QueryParamErrors{{Param: "gcond", Value: gcond, Err: err}}
+	}
+	var errs QueryParamErrors
+	for _, name := range node.groupNames() {
+		if !groups[name] {
+			errs = append(errs, &QueryParamError{Param: "gcond", Value: name, Err: ErrUnknownGroup})
+		}
+	}
+	return errs
+}
+
+// 根据字段类型校验条件的值，like 条件不校验类型
+func checkConditionValue(columnType reflect.Type, op string, value string) bool {
+	switch op {
+	case "~=", "!~=", "~<=", "~>=", "!~<=", "!~>=":
+		return true
+	case "=", "!=":
+		if strings.ToLower(value) == "null" {
+			return true
+		}
+	case "?=", "!?=":
+		for _, v := range strings.Split(value, ",") {
+			if !checkValue(columnType, v) {
+				return false
+			}
+		}
+		return true
+	case "^=", "!^=":
+		values := strings.Split(value, ",")
+		return len(values) == 2 && checkValue(columnType, values[0]) && checkValue(columnType, values[1])
+	}
+	return checkValue(columnType, value)
+}
+
+func checkValue(columnType reflect.Type, value string) bool {
+	if columnType.Kind() == reflect.Pointer {
+		columnType = columnType.Elem()
+	}
+	var err error
+	switch columnType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		_, err = strconv.ParseInt(value, 10, columnType.Bits())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		_, err = strconv.ParseUint(value, 10, columnType.Bits())
+	case reflect.Float32, reflect.Float64:
+		_, err = strconv.ParseFloat(value, columnType.Bits())
+	case reflect.Bool:
+		_, err = strconv.ParseBool(value)
+	case reflect.Struct:
+		if columnType == reflect.TypeOf(time.Time{}) {
+			return isTime(value)
+		}
+	}
+	return err == nil
+}
+
+func isTime(value string) bool {
+	_, ok := parseTime(value)
+	return ok
+}
+
+// parseTime 按照 timeLayouts 中的格式解析时间
+func parseTime(value string) (time.Time, bool) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
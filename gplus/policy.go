@@ -0,0 +1,198 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gplus
+
+import (
+	"net/url"
+	"strings"
+)
+
+// QueryPolicy BuildQueryWithPolicy 和 BuildQueryFromJSONWithPolicy 的校验策略，字段使用实体的字段指针设置
+// Tips: 字段为白名单，没有设置的字段不允许过滤、排序和查询
+type QueryPolicy struct {
+	FilterColumns []any            // 允许过滤的字段
+	SortColumns   []any            // 允许排序的字段
+	SelectColumns []any            // 允许 select 和 omit 的字段
+	Operators     map[any][]string // 字段允许的操作符，例如：{&u.Age: {">", "<"}}，没有设置的字段允许所有操作符
+	MaxConditions int              // 最多的过滤条件数量，0 表示不限制
+	MaxDepth      int              // gcond 最大的括号嵌套深度，0 表示不限制
+}
+
+// BuildQueryWithPolicy 根据策略校验url参数后构建查询条件，参数不满足策略时返回 *QueryParamError
+func BuildQueryWithPolicy[T any](queryParams url.Values, policy *QueryPolicy) (*QueryCond[T], error) {
+	if policy != nil {
+		if err := policy.check(queryParams); err != nil {
+			return nil, err
+		}
+	}
+	return BuildQuery[T](queryParams), nil
+}
+
+func (p *QueryPolicy) check(queryParams url.Values) error {
+	columnCondMap, conditionMap, gcond := parseParams(queryParams)
+
+	filterColumns := toColumnSet(p.FilterColumns)
+	operators := make(map[string][]string, len(p.Operators))
+	for column, ops := range p.Operators {
+		operators[getColumnName(column)] = ops
+	}
+	var count int
+	for _, conditions := range columnCondMap {
+		for _, condition := range conditions {
+			count++
+			if !filterColumns[condition.ColumnName] {
+				return &QueryParamError{Param: "q", Column: condition.ColumnName, Op: condition.Op, Err: ErrColumnNotAllowed}
+			}
+			if ops, ok := operators[condition.ColumnName]; ok && !containsString(ops, condition.Op) {
+				return &QueryParamError{Param: "q", Column: condition.ColumnName, Op: condition.Op, Err: ErrOperatorNotAllowed}
+			}
+		}
+	}
+	if p.MaxConditions > 0 && count > p.MaxConditions {
+		return &QueryParamError{Param: "q", Err: ErrTooManyConditions}
+	}
+
+	for _, param := range []string{"sort", "select", "omit"} {
+		if value, ok := conditionMap[param]; ok {
+			if err := p.checkColumns(param, strings.Split(value, ",")); err != nil {
+				return err
+			}
+		}
+	}
+
+	if gcond == "" {
+		return nil
+	}
+	// gcond 有误时 BuildQuery 会忽略所有条件，需要校验语法以及引用的分组是否存在
+	groups := make(map[string]bool, len(columnCondMap))
+	for group := range columnCondMap {
+		if group != "default" {
+			groups[group] = true
+		}
+	}
+	if errs := checkGroupCondition(gcond, groups); len(errs) > 0 {
+		return errs[0]
+	}
+	if p.MaxDepth > 0 && getNestingDepth(gcond) > p.MaxDepth {
+		return &QueryParamError{Param: "gcond", Err: ErrNestingTooDeep}
+	}
+	return nil
+}
+
+// checkJSON 校验JSON查询条件，and、or 的嵌套层数作为嵌套深度
+func (p *QueryPolicy) checkJSON(jsonQuery *JSONQuery) error {
+	filterColumns := toColumnSet(p.FilterColumns)
+	operators := make(map[string][]string, len(p.Operators))
+	for column, ops := range p.Operators {
+		operators[getColumnName(column)] = ops
+	}
+	var count int
+	var checkFilter func(filter *JSONFilter, depth int) error
+	checkFilter = func(filter *JSONFilter, depth int) error {
+		if p.MaxDepth > 0 && depth > p.MaxDepth {
+			return &QueryParamError{Param: "json", Err: ErrNestingTooDeep}
+		}
+		if filter.Field != "" || filter.Op != "" {
+			count++
+			if !filterColumns[filter.Field] {
+				return &QueryParamError{Param: "json", Column: filter.Field, Op: filter.Op, Err: ErrColumnNotAllowed}
+			}
+			if ops, ok := operators[filter.Field]; ok && !containsString(ops, filter.Op) {
+				return &QueryParamError{Param: "json", Column: filter.Field, Op: filter.Op, Err: ErrOperatorNotAllowed}
+			}
+		}
+		for _, children := range [][]*JSONFilter{filter.And, filter.Or} {
+			for _, child := range children {
+				childDepth := depth
+				if len(child.And) > 0 || len(child.Or) > 0 {
+					childDepth++
+				}
+				if err := checkFilter(child, childDepth); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := checkFilter(&jsonQuery.JSONFilter, 0); err != nil {
+		return err
+	}
+	if p.MaxConditions > 0 && count > p.MaxConditions {
+		return &QueryParamError{Param: "json", Err: ErrTooManyConditions}
+	}
+
+	if err := p.checkColumns("sort", jsonQuery.Sort); err != nil {
+		return err
+	}
+	if err := p.checkColumns("select", jsonQuery.Select); err != nil {
+		return err
+	}
+	return p.checkColumns("omit", jsonQuery.Omit)
+}
+
+// 校验排序、select 和 omit 的字段是否在白名单中
+func (p *QueryPolicy) checkColumns(param string, columns []string) error {
+	allowColumns := p.SelectColumns
+	if param == "sort" {
+		allowColumns = p.SortColumns
+	}
+	columnSet := toColumnSet(allowColumns)
+	for _, column := range columns {
+		if param == "sort" {
+			column = strings.TrimLeft(column, "-")
+		}
+		if !columnSet[column] {
+			return &QueryParamError{Param: param, Column: column, Err: ErrColumnNotAllowed}
+		}
+	}
+	return nil
+}
+
+func toColumnSet(columns []any) map[string]bool {
+	columnSet := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		columnSet[getColumnName(column)] = true
+	}
+	return columnSet
+}
+
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
+
+// 获取 gcond 中括号的最大嵌套深度
+func getNestingDepth(gcond string) int {
+	var depth, maxDepth int
+	for _, char := range gcond {
+		switch string(char) {
+		case "(":
+			depth++
+			if depth > maxDepth {
+				maxDepth = depth
+			}
+		case ")":
+			depth--
+		}
+	}
+	return maxDepth
+}
@@ -0,0 +1,79 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gplus
+
+import (
+	"gorm.io/gorm"
+	"strings"
+)
+
+// ToSQL 生成查询语句和参数，不会执行sql，与 SelectList 生成的sql一致，包括逻辑删除、多租户等条件
+func ToSQL[T any](q *QueryCond[T], opts ...OptionFunc) (string, []any, error) {
+	_, resultDb := SelectList[T](q, append(opts[:len(opts):len(opts)], dryRun())...)
+	return getStatementSql(resultDb)
+}
+
+// ToCountSQL 生成查询数量的语句和参数，与 SelectCount 生成的sql一致
+func ToCountSQL[T any](q *QueryCond[T], opts ...OptionFunc) (string, []any, error) {
+	_, resultDb := SelectCount[T](q, append(opts[:len(opts):len(opts)], dryRun())...)
+	return getStatementSql(resultDb)
+}
+
+// ToUpdateSQL 生成更新语句和参数，与 Update 生成的sql一致，包括乐观锁的版本号
+func ToUpdateSQL[T any](q *QueryCond[T], opts ...OptionFunc) (string, []any, error) {
+	return getStatementSql(Update[T](q, append(opts[:len(opts):len(opts)], dryRun())...))
+}
+
+// ToDeleteSQL 生成删除语句和参数，与 Delete 生成的sql一致，配置了逻辑删除时为更新语句
+func ToDeleteSQL[T any](q *QueryCond[T], opts ...OptionFunc) (string, []any, error) {
+	return getStatementSql(Delete[T](q, append(opts[:len(opts):len(opts)], dryRun())...))
+}
+
+// Explain 执行查询语句的执行计划，返回数据库的 EXPLAIN 结果，SQLite 使用 EXPLAIN QUERY PLAN
+func Explain[T any](q *QueryCond[T], opts ...OptionFunc) ([]map[string]any, error) {
+	sql, args, err := ToSQL[T](q, opts...)
+	if err != nil {
+		return nil, err
+	}
+	db := getDb(opts...)
+	explain := "EXPLAIN "
+	if db.Dialector.Name() == "sqlite" {
+		explain = "EXPLAIN QUERY PLAN "
+	}
+	var results []map[string]any
+	err = db.Raw(explain+sql, args...).Scan(&results).Error
+	return results, err
+}
+
+// dryRun 使用 DryRun 模式的会话，只生成sql不执行，需要放在最后，保留前面设置的 Db
+func dryRun() OptionFunc {
+	return func(o *Option) {
+		db := o.Db
+		if db == nil {
+			db = globalDb
+		}
+		o.Db = db.Session(&gorm.Session{DryRun: true})
+	}
+}
+
+func getStatementSql(db *gorm.DB) (string, []any, error) {
+	if db.Error != nil {
+		return "", nil, db.Error
+	}
+	return strings.TrimSpace(db.Statement.SQL.String()), db.Statement.Vars, nil
+}
@@ -20,31 +20,50 @@ package gplus
 import (
 	"fmt"
 	"github.com/acmestack/gorm-plus/constants"
+	"gorm.io/gorm"
 	"reflect"
 	"strings"
 )
 
 type QueryCond[T any] struct {
-	selectColumns    []string
-	omitColumns      []string
-	distinctColumns  []string
+	selectColumns    []any
+	omitColumns      []any
+	distinctColumns  []any
 	queryExpressions []any
-	orderBuilder     strings.Builder
-	groupBuilder     strings.Builder
+	orders           []orderColumn
+	groupColumns     []any
 	havingBuilder    strings.Builder
 	havingArgs       []any
-	queryArgs        []any
+	havingConds      []*QueryCond[T]
+	fromQuery        subQuery
+	fromAlias        string
+	fromTable        string
+	ctes             []*cte
 	last             any
 	limit            *int
 	offset           int
 	updateMap        map[string]any
 	columnTypeMap    map[string]reflect.Type
+	joins            []*joinCond[T]
+}
+
+type joinCond[T any] struct {
+	joinType   string
+	model      any
+	tableName  string
+	column     any
+	joinColumn any
+	onQuery    *QueryCond[T]
 }
 
 func (q *QueryCond[T]) getSqlSegment() string {
 	return ""
 }
 
+func (q *QueryCond[T]) buildSubQuery(db *gorm.DB) *gorm.DB {
+	return buildCondition[T](q, Db(db))
+}
+
 // NewQuery 构建查询条件
 func NewQuery[T any]() (*QueryCond[T], *T) {
 	q := &QueryCond[T]{}
@@ -208,45 +227,70 @@ func (q *QueryCond[T]) NotBetween(column any, start, end any) *QueryCond[T] {
 	return q
 }
 
+// EqColumn 字段等于字段 表1.字段1 = 表2.字段2，主要用于关联子查询
+func (q *QueryCond[T]) EqColumn(column any, refColumn any) *QueryCond[T] {
+	q.addExpression(&columnPointer{column: column, qualified: true}, &sqlKeyword{keyword: constants.Eq},
+		&columnPointer{column: refColumn, qualified: true})
+	return q
+}
+
+// InSub 字段 IN (子查询)
+func (q *QueryCond[T]) InSub(column any, sub subQuery) *QueryCond[T] {
+	q.addExpression(&columnPointer{column: column}, &sqlKeyword{keyword: constants.In}, &subQueryValue{query: sub})
+	return q
+}
+
+// NotInSub 字段 NOT IN (子查询)
+func (q *QueryCond[T]) NotInSub(column any, sub subQuery) *QueryCond[T] {
+	q.addExpression(&columnPointer{column: column}, &sqlKeyword{keyword: constants.Not + " " + constants.In}, &subQueryValue{query: sub})
+	return q
+}
+
+// ExistsSub EXISTS (子查询)
+func (q *QueryCond[T]) ExistsSub(sub subQuery) *QueryCond[T] {
+	q.addExpression(&sqlKeyword{keyword: constants.Exists}, &subQueryValue{query: sub})
+	return q
+}
+
+// NotExistsSub NOT EXISTS (子查询)
+func (q *QueryCond[T]) NotExistsSub(sub subQuery) *QueryCond[T] {
+	q.addExpression(&sqlKeyword{keyword: constants.Not + " " + constants.Exists}, &subQueryValue{query: sub})
+	return q
+}
+
 // Distinct 去除重复字段值
 func (q *QueryCond[T]) Distinct(columns ...any) *QueryCond[T] {
-	for _, v := range columns {
-		q.distinctColumns = append(q.distinctColumns, getColumnName(v))
-	}
+	q.distinctColumns = append(q.distinctColumns, columns...)
+	return q
+}
+
+// Limit 限制查询的记录数：LIMIT 记录数
+func (q *QueryCond[T]) Limit(limit int) *QueryCond[T] {
+	q.limit = &limit
+	return q
+}
+
+// Offset 跳过的记录数：OFFSET 记录数
+func (q *QueryCond[T]) Offset(offset int) *QueryCond[T] {
+	q.offset = offset
 	return q
 }
 
 // Group 分组：GROUP BY 字段1,字段2
 func (q *QueryCond[T]) Group(columns ...any) *QueryCond[T] {
-	for _, v := range columns {
-		columnName := getColumnName(v)
-		if q.groupBuilder.Len() > 0 {
-			q.groupBuilder.WriteString(constants.Comma)
-		}
-		q.groupBuilder.WriteString(columnName)
-	}
+	q.groupColumns = append(q.groupColumns, columns...)
 	return q
 }
 
 // OrderByDesc 排序：ORDER BY 字段1,字段2 Desc
 func (q *QueryCond[T]) OrderByDesc(columns ...any) *QueryCond[T] {
-	var columnNames []string
-	for _, v := range columns {
-		columnName := getColumnName(v)
-		columnNames = append(columnNames, columnName)
-	}
-	q.buildOrder(constants.Desc, columnNames...)
+	q.buildOrder(constants.Desc, columns...)
 	return q
 }
 
 // OrderByAsc 排序：ORDER BY 字段1,字段2 ASC
 func (q *QueryCond[T]) OrderByAsc(columns ...any) *QueryCond[T] {
-	var columnNames []string
-	for _, v := range columns {
-		columnName := getColumnName(v)
-		columnNames = append(columnNames, columnName)
-	}
-	q.buildOrder(constants.Asc, columnNames...)
+	q.buildOrder(constants.Asc, columns...)
 	return q
 }
 
@@ -264,6 +308,24 @@ func (q *QueryCond[T]) Having(having string, args ...any) *QueryCond[T] {
 	return q
 }
 
+// From 从子查询中查询：FROM (子查询) AS 别名，可以在外层查询中过滤子查询计算的字段，例如窗口函数的排名
+// 子查询已经包含逻辑删除和租户条件，外层查询不再重复添加
+func (q *QueryCond[T]) From(sub subQuery, alias string) *QueryCond[T] {
+	q.fromQuery = sub
+	q.fromAlias = alias
+	return q
+}
+
+// HavingCond 使用条件构建 HAVING 语句，字段可以使用聚合函数，例如：
+// HavingCond(func(h *QueryCond[T]) { h.Gt(Sum(&u.Score), 100).Or().In(Count(&u.ID), []int{1, 2}) })
+// 多次调用时使用 AND 连接，包含 OR 的条件会加上括号，并与 Having 设置的 SQL 使用 AND 连接
+func (q *QueryCond[T]) HavingCond(fn func(h *QueryCond[T])) *QueryCond[T] {
+	havingQuery := &QueryCond[T]{}
+	fn(havingQuery)
+	q.havingConds = append(q.havingConds, havingQuery)
+	return q
+}
+
 // And 拼接 AND
 func (q *QueryCond[T]) And(fn ...func(q *QueryCond[T])) *QueryCond[T] {
 	if len(fn) > 0 {
@@ -294,21 +356,84 @@ func (q *QueryCond[T]) Or(fn ...func(q *QueryCond[T])) *QueryCond[T] {
 	return q
 }
 
+// Clone 深拷贝查询条件，可以在公共的查询条件基础上追加条件，不影响原来的查询条件
+func (q *QueryCond[T]) Clone() *QueryCond[T] {
+	if q == nil {
+		return nil
+	}
+	c := &QueryCond[T]{
+		selectColumns:   append([]any(nil), q.selectColumns...),
+		omitColumns:     append([]any(nil), q.omitColumns...),
+		distinctColumns: append([]any(nil), q.distinctColumns...),
+		orders:          append([]orderColumn(nil), q.orders...),
+		groupColumns:    append([]any(nil), q.groupColumns...),
+		havingArgs:      append([]any(nil), q.havingArgs...),
+		last:            q.last,
+		offset:          q.offset,
+		fromQuery:       q.fromQuery,
+		fromAlias:       q.fromAlias,
+		fromTable:       q.fromTable,
+		ctes:            append([]*cte(nil), q.ctes...),
+		columnTypeMap:   q.columnTypeMap,
+	}
+	c.havingBuilder.WriteString(q.havingBuilder.String())
+	for _, havingQuery := range q.havingConds {
+		c.havingConds = append(c.havingConds, havingQuery.Clone())
+	}
+	if q.limit != nil {
+		limit := *q.limit
+		c.limit = &limit
+	}
+	if q.updateMap != nil {
+		c.updateMap = make(map[string]any, len(q.updateMap))
+		for column, value := range q.updateMap {
+			c.updateMap[column] = value
+		}
+	}
+
+	// 嵌套的条件需要深拷贝，其他表达式创建后不会被修改，可以共用
+	c.queryExpressions = make([]any, len(q.queryExpressions))
+	for i, expression := range q.queryExpressions {
+		if nestQuery, ok := expression.(*QueryCond[T]); ok {
+			nestClone := nestQuery.Clone()
+			if q.last == expression {
+				c.last = nestClone
+			}
+			expression = nestClone
+		}
+		c.queryExpressions[i] = expression
+	}
+
+	for _, join := range q.joins {
+		joinClone := *join
+		joinClone.onQuery = join.onQuery.Clone()
+		c.joins = append(c.joins, &joinClone)
+	}
+	return c
+}
+
+// Not 拼接 NOT ( 子条件 )
+func (q *QueryCond[T]) Not(fn func(q *QueryCond[T])) *QueryCond[T] {
+	nestQuery := &QueryCond[T]{}
+	fn(nestQuery)
+	if len(nestQuery.queryExpressions) == 0 {
+		return q
+	}
+	q.addAndCondIfNeed()
+	q.queryExpressions = append(q.queryExpressions, &sqlKeyword{keyword: constants.Not}, nestQuery)
+	q.last = nestQuery
+	return q
+}
+
 // Select 查询字段
 func (q *QueryCond[T]) Select(columns ...any) *QueryCond[T] {
-	for _, v := range columns {
-		columnName := getColumnName(v)
-		q.selectColumns = append(q.selectColumns, columnName)
-	}
+	q.selectColumns = append(q.selectColumns, columns...)
 	return q
 }
 
 // Omit 忽略字段
 func (q *QueryCond[T]) Omit(columns ...any) *QueryCond[T] {
-	for _, v := range columns {
-		columnName := getColumnName(v)
-		q.omitColumns = append(q.omitColumns, columnName)
-	}
+	q.omitColumns = append(q.omitColumns, columns...)
 	return q
 }
 
@@ -322,6 +447,24 @@ func (q *QueryCond[T]) Set(column any, val any) *QueryCond[T] {
 	return q
 }
 
+// InnerJoin 内连接：INNER JOIN 表 ON 字段1 = 字段2
+// 连表查询时字段名会自动带上表名
+func (q *QueryCond[T]) InnerJoin(model any, column any, joinColumn any, fn ...func(on *QueryCond[T])) *QueryCond[T] {
+	return q.join(constants.InnerJoin, model, column, joinColumn, fn...)
+}
+
+// LeftJoin 左连接：LEFT JOIN 表 ON 字段1 = 字段2
+// 连表查询时字段名会自动带上表名
+func (q *QueryCond[T]) LeftJoin(model any, column any, joinColumn any, fn ...func(on *QueryCond[T])) *QueryCond[T] {
+	return q.join(constants.LeftJoin, model, column, joinColumn, fn...)
+}
+
+// RightJoin 右连接：RIGHT JOIN 表 ON 字段1 = 字段2
+// 连表查询时字段名会自动带上表名
+func (q *QueryCond[T]) RightJoin(model any, column any, joinColumn any, fn ...func(on *QueryCond[T])) *QueryCond[T] {
+	return q.join(constants.RightJoin, model, column, joinColumn, fn...)
+}
+
 /*
 * 自定义条件
  */
@@ -654,6 +797,69 @@ func (q *QueryCond[T]) OrInCond(cond bool, column any, val any) *QueryCond[T] {
 	return q
 }
 
+func (q *QueryCond[T]) join(joinType string, model any, column any, joinColumn any, fn ...func(on *QueryCond[T])) *QueryCond[T] {
+	jc := &joinCond[T]{joinType: joinType, column: column, joinColumn: joinColumn}
+	// 表名可以直接传入字符串，例如公共表表达式的名称
+	if tableName, ok := model.(string); ok {
+		jc.tableName = tableName
+	} else {
+		jc.model = model
+		jc.tableName = getTableName(model)
+	}
+	// ON 除了关联字段以外的附加条件
+	if len(fn) > 0 {
+		onQuery := &QueryCond[T]{}
+		fn[0](onQuery)
+		jc.onQuery = onQuery
+	}
+	q.joins = append(q.joins, jc)
+	return q
+}
+
+// isFromSubQuery 是否从子查询或者公共表表达式中查询
+func (q *QueryCond[T]) isFromSubQuery() bool {
+	return q != nil && (q.fromQuery != nil || q.isFromCte())
+}
+
+// orderColumn 排序字段和排序方式
+type orderColumn struct {
+	column    any
+	orderType string
+}
+
+// buildColumns 获取字段名和函数参数，qualified 为 true 时字段名带上表名，避免连表时多表存在相同字段名
+// 包含参数或者依赖数据库类型的函数使用 ? 占位，执行时生成
+func buildColumns(columns []any, qualified bool) ([]string, []any) {
+	columnNames := make([]string, 0, len(columns))
+	var args []any
+	for _, v := range columns {
+		if f, ok := v.(*Function); ok && !f.isStatic() {
+			columnNames = append(columnNames, "?")
+			args = append(args, f)
+			continue
+		}
+		if qualified {
+			columnNames = append(columnNames, getTableColumnName(v))
+			continue
+		}
+		columnNames = append(columnNames, getColumnName(v))
+	}
+	return columnNames, args
+}
+
+// buildOrderSql 构建排序语句和函数参数：字段1 ASC,字段2 DESC
+func buildOrderSql(orders []orderColumn, qualified bool) (string, []any) {
+	columns := make([]any, len(orders))
+	for i, order := range orders {
+		columns[i] = order.column
+	}
+	columnNames, args := buildColumns(columns, qualified)
+	for i, order := range orders {
+		columnNames[i] += " " + order.orderType
+	}
+	return strings.Join(columnNames, constants.Comma), args
+}
+
 func (q *QueryCond[T]) addExpression(sqlSegments ...SqlSegment) {
 	if len(sqlSegments) == 1 {
 		q.handleSingle(sqlSegments[0])
@@ -738,13 +944,12 @@ func (q *QueryCond[T]) buildSqlSegment(column any, condType string, values ...an
 	return sqlSegments
 }
 
-func (q *QueryCond[T]) buildOrder(orderType string, columns ...string) {
+func (q *QueryCond[T]) buildOrder(orderType string, columns ...any) {
 	for _, v := range columns {
-		if q.orderBuilder.Len() > 0 {
-			q.orderBuilder.WriteString(constants.Comma)
-		}
-		q.orderBuilder.WriteString(v)
-		q.orderBuilder.WriteString(" ")
-		q.orderBuilder.WriteString(orderType)
+		q.orders = append(q.orders, orderColumn{column: v, orderType: orderType})
 	}
 }
+
+func (q *QueryCond[T]) getExpressions() []any {
+	return q.queryExpressions
+}
@@ -0,0 +1,98 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gplus
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// 查询参数校验失败的原因，可以通过 errors.Is 判断
+var (
+	ErrColumnNotAllowed   = errors.New("column not allowed")
+	ErrOperatorNotAllowed = errors.New("operator not allowed")
+	ErrTooManyConditions  = errors.New("too many conditions")
+	ErrNestingTooDeep     = errors.New("nesting too deep")
+
+	ErrUnknownColumn         = errors.New("unknown column")
+	ErrInvalidValue          = errors.New("invalid value")
+	ErrInvalidCondition      = errors.New("invalid condition")
+	ErrUnknownGroup          = errors.New("unknown group")
+	ErrUnbalancedParentheses = errors.New("unbalanced parentheses")
+)
+
+// QueryParamError 查询参数错误，记录出错的参数、字段、操作符和原因
+type QueryParamError struct {
+	Param  string // 参数名称，例如：q、sort、select、omit、gcond
+	Column string // 字段名称
+	Op     string // 操作符
+	Value  string // 参数值
+	Err    error  // 错误原因
+}
+
+func (e *QueryParamError) Error() string {
+	var builder strings.Builder
+	builder.WriteString("gplus: invalid query param " + e.Param)
+	if e.Column != "" {
+		builder.WriteString(", column: " + e.Column)
+	}
+	if e.Op != "" {
+		builder.WriteString(", op: " + e.Op)
+	}
+	if e.Value != "" {
+		builder.WriteString(", value: " + e.Value)
+	}
+	builder.WriteString(fmt.Sprintf(", reason: %v", e.Err))
+	return builder.String()
+}
+
+func (e *QueryParamError) Unwrap() error {
+	return e.Err
+}
+
+// QueryParamErrors 查询参数错误列表，ParseQuery 会收集所有的参数错误
+type QueryParamErrors []*QueryParamError
+
+func (e QueryParamErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Is 支持通过 errors.Is 判断列表中的错误，errors.Is 在 Go 1.20 之前不支持 Unwrap() []error
+func (e QueryParamErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As 支持通过 errors.As 获取列表中第一个匹配的错误，例如 *QueryParamError
+func (e QueryParamErrors) As(target any) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -17,15 +17,21 @@
 
 package gplus
 
+import "gorm.io/gorm"
+
 type SqlSegment interface {
 	getSqlSegment() string
 }
 
 type columnPointer struct {
-	column any
+	column    any
+	qualified bool
 }
 
 func (cp *columnPointer) getSqlSegment() string {
+	if cp.qualified {
+		return getTableColumnName(cp.column)
+	}
 	return getColumnName(cp.column)
 }
 
@@ -44,3 +50,16 @@ type columnValue struct {
 func (cv *columnValue) getSqlSegment() string {
 	return ""
 }
+
+// subQuery 子查询，任意实体类型的 QueryCond 都可以作为子查询
+type subQuery interface {
+	buildSubQuery(db *gorm.DB) *gorm.DB
+}
+
+type subQueryValue struct {
+	query subQuery
+}
+
+func (sv *subQueryValue) getSqlSegment() string {
+	return ""
+}
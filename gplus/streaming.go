@@ -0,0 +1,297 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gplus
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/acmestack/gorm-plus/constants"
+	"gorm.io/gorm"
+	"reflect"
+	"strings"
+)
+
+// ErrInvalidCursor 流式分页的游标格式错误
+var ErrInvalidCursor = errors.New("gplus: invalid streaming page cursor")
+
+// 支持行值比较 (a, b) > (?, ?) 的数据库，其他数据库展开成 OR 条件
+var rowValueDialects = map[string]bool{"mysql": true, "postgres": true, "sqlite": true}
+
+// SortKey 流式分页的排序字段
+type SortKey struct {
+	Column any  // 排序字段
+	Desc   bool // 是否降序
+}
+
+// SortAsc 升序排序字段
+func SortAsc(column any) SortKey {
+	return SortKey{Column: column}
+}
+
+// SortDesc 降序排序字段
+func SortDesc(column any) SortKey {
+	return SortKey{Column: column, Desc: true}
+}
+
+// SortBy 设置流式分页的排序字段，支持多个字段组合排序，设置后忽略 ColumnName
+// Tips: 排序字段的组合需要唯一，一般最后加上主键，例如：created_at DESC, id DESC
+func (p *StreamingPage[T, V]) SortBy(keys ...SortKey) *StreamingPage[T, V] {
+	p.SortKeys = append(p.SortKeys, keys...)
+	return p
+}
+
+// 获取排序字段，没有设置 SortKeys 时使用 ColumnName 升序排序
+func (p *StreamingPage[T, V]) sortKeys() []SortKey {
+	if len(p.SortKeys) > 0 {
+		return p.SortKeys
+	}
+	return []SortKey{{Column: p.ColumnName}}
+}
+
+// streamingPaginate 流式分页，根据自增ID、雪花ID、时间等数值类型或者时间类型分页
+// 设置了 SortKeys 或者 Cursor 时，按照排序字段和游标进行 keyset 分页，M 为解析游标使用的实体类型
+// 多查询一条记录，用来判断是否还有下一页（上一页）
+// Tips: 相比于 offset 分页性能更好，走的是 range，缺点是没办法跳页查询
+func streamingPaginate[M any, T any, V Comparable](p *StreamingPage[T, V]) func(db *gorm.DB) *gorm.DB {
+	limit := p.Limit
+	if limit > 0 {
+		limit++
+	}
+	if len(p.SortKeys) == 0 && p.Cursor == "" {
+		column := getColumnName(p.ColumnName)
+		startValue := p.StartValue
+		return func(db *gorm.DB) *gorm.DB {
+			// 下一页
+			if p.Forward {
+				return db.Where(fmt.Sprintf("%v > ?", column), startValue).Order(fmt.Sprintf("%v ASC", column)).Limit(limit)
+			}
+			// 上一页
+			return db.Where(fmt.Sprintf("%v < ?", column), startValue).Order(fmt.Sprintf("%v DESC", column)).Limit(limit)
+		}
+	}
+
+	keys := p.sortKeys()
+	columns := getSortColumns(keys)
+	// 上一页时所有字段反向排序
+	descs := make([]bool, len(keys))
+	for i, key := range keys {
+		descs[i] = key.Desc != !p.Forward
+	}
+	return func(db *gorm.DB) *gorm.DB {
+		if p.Cursor != "" {
+			values, err := decodeCursor[M](db, p.Cursor, columns)
+			if err != nil {
+				db.AddError(err)
+				return db
+			}
+			sql, args := buildKeysetCondition(db.Dialector.Name(), columns, descs, values)
+			db = db.Where(sql, args...)
+		}
+		for i, column := range columns {
+			if descs[i] {
+				db = db.Order(column + " " + constants.Desc)
+			} else {
+				db = db.Order(column)
+			}
+		}
+		return db.Limit(limit)
+	}
+}
+
+// 构建 keyset 分页条件，所有字段排序方向一致且数据库支持时使用行值比较，
+// 否则展开成：a > ? OR (a = ? AND b > ?)
+func buildKeysetCondition(dialect string, columns []string, descs []bool, values []any) (string, []any) {
+	sameDirection := true
+	for _, desc := range descs {
+		if desc != descs[0] {
+			sameDirection = false
+			break
+		}
+	}
+
+	if len(columns) == 1 {
+		return fmt.Sprintf("%v %v ?", columns[0], compareOperator(descs[0])), values
+	}
+
+	if sameDirection && rowValueDialects[dialect] {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+		return fmt.Sprintf("(%v) %v (%v)", strings.Join(columns, ", "), compareOperator(descs[0]), placeholders), values
+	}
+
+	var conditions []string
+	var args []any
+	for i := range columns {
+		var segments []string
+		for j := 0; j < i; j++ {
+			segments = append(segments, fmt.Sprintf("%v = ?", columns[j]))
+			args = append(args, values[j])
+		}
+		segments = append(segments, fmt.Sprintf("%v %v ?", columns[i], compareOperator(descs[i])))
+		args = append(args, values[i])
+		conditions = append(conditions, constants.LeftBracket+strings.Join(segments, " "+constants.And+" ")+constants.RightBracket)
+	}
+	return constants.LeftBracket + strings.Join(conditions, " "+constants.Or+" ") + constants.RightBracket, args
+}
+
+func compareOperator(desc bool) string {
+	if desc {
+		return "<"
+	}
+	return ">"
+}
+
+func getSortColumns(keys []SortKey) []string {
+	columns := make([]string, len(keys))
+	for i, key := range keys {
+		columns[i] = getColumnName(key.Column)
+	}
+	return columns
+}
+
+// 编码游标，游标为排序字段值的JSON数组经过base64编码后的字符串，对调用方不透明
+func encodeCursor(values []any) (string, error) {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// 解码游标，根据实体字段的类型还原排序字段的值
+func decodeCursor[T any](db *gorm.DB, cursor string, columns []string) ([]any, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var raws []json.RawMessage
+	if err = json.Unmarshal(data, &raws); err != nil || len(raws) != len(columns) {
+		return nil, ErrInvalidCursor
+	}
+
+	stmt := &gorm.Statement{DB: db}
+	if err = stmt.Parse(new(T)); err != nil {
+		return nil, err
+	}
+	values := make([]any, len(columns))
+	for i, column := range columns {
+		var value reflect.Value
+		if field := stmt.Schema.LookUpField(column); field != nil {
+			value = reflect.New(field.FieldType)
+		} else {
+			value = reflect.New(reflect.TypeOf((*any)(nil)).Elem())
+		}
+		if err = json.Unmarshal(raws[i], value.Interface()); err != nil {
+			return nil, ErrInvalidCursor
+		}
+		values[i] = value.Elem().Interface()
+	}
+	return values, nil
+}
+
+// 根据记录的排序字段值生成游标，记录无法解析或者不存在排序字段时返回空字符串
+func buildCursor(db *gorm.DB, record any, columns []string) (string, error) {
+	values, ok := getRecordValues(db, record, columns)
+	if !ok {
+		return "", nil
+	}
+	return encodeCursor(values)
+}
+
+// 获取记录中指定字段的值，记录无法解析或者不存在字段时返回false
+func getRecordValues(db *gorm.DB, record any, columns []string) ([]any, bool) {
+	values := make([]any, len(columns))
+	if recordMap, ok := record.(map[string]any); ok {
+		for i, column := range columns {
+			value, exists := recordMap[column]
+			if !exists {
+				return nil, false
+			}
+			values[i] = value
+		}
+		return values, true
+	}
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(record); err != nil {
+		return nil, false
+	}
+	recordValue := reflect.Indirect(reflect.ValueOf(record))
+	for i, column := range columns {
+		field := stmt.Schema.LookUpField(column)
+		if field == nil {
+			return nil, false
+		}
+		values[i], _ = field.ValueOf(db.Statement.Context, recordValue)
+	}
+	return values, true
+}
+
+// fillStreamingPage 根据查询结果设置翻页信息，去掉多查询的一条记录，上一页的记录恢复成正常的排序
+func fillStreamingPage[T any, V Comparable, R any](page *StreamingPage[T, V], db *gorm.DB, results []R) []R {
+	hasMore := page.Limit > 0 && len(results) > page.Limit
+	if hasMore {
+		results = results[:page.Limit]
+	}
+	// 有起始值或者游标时，说明反方向还有记录
+	hasStart := page.Cursor != "" || !reflect.ValueOf(page.StartValue).IsZero()
+	if page.Forward {
+		page.HasNext, page.HasPrev = hasMore, hasStart
+	} else {
+		page.HasNext, page.HasPrev = hasStart, hasMore
+		for i, j := 0, len(results)-1; i < j; i, j = i+1, j-1 {
+			results[i], results[j] = results[j], results[i]
+		}
+	}
+	if db.Error != nil || len(results) == 0 {
+		return results
+	}
+
+	first, last := any(results[0]), any(results[len(results)-1])
+	if page.ColumnName != nil {
+		columns := []string{getColumnName(page.ColumnName)}
+		page.PrevStartValue, _ = getStartValue[V](db, first, columns)
+		page.NextStartValue, _ = getStartValue[V](db, last, columns)
+	}
+
+	columns := getSortColumns(page.sortKeys())
+	var err error
+	if page.PrevCursor, err = buildCursor(db, first, columns); err != nil {
+		db.AddError(err)
+	}
+	if page.NextCursor, err = buildCursor(db, last, columns); err != nil {
+		db.AddError(err)
+	}
+	return results
+}
+
+// 获取记录中分页字段的值，并转换成分页起始值的类型
+func getStartValue[V Comparable](db *gorm.DB, record any, columns []string) (V, bool) {
+	var startValue V
+	values, ok := getRecordValues(db, record, columns)
+	if !ok || values[0] == nil {
+		return startValue, false
+	}
+	value := reflect.Indirect(reflect.ValueOf(values[0]))
+	startType := reflect.TypeOf(startValue)
+	if !value.IsValid() || !value.Type().ConvertibleTo(startType) {
+		return startValue, false
+	}
+	return value.Convert(startType).Interface().(V), true
+}
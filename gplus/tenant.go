@@ -0,0 +1,112 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gplus
+
+import (
+	"context"
+	"errors"
+	"github.com/acmestack/gorm-plus/constants"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	"reflect"
+)
+
+// ErrTenantNotFound 上下文中获取不到租户ID，拒绝执行语句
+var ErrTenantNotFound = errors.New("gplus: tenant id not found in context")
+
+const defaultTenantColumn = "tenant_id"
+
+// TenantPlugin 多租户插件，自动给生成的语句添加租户条件，插入记录时自动填充租户字段
+type TenantPlugin struct {
+	Column       string                                // 租户字段名，默认为 tenant_id
+	TenantId     func(ctx context.Context) (any, bool) // 从上下文中获取租户ID，获取不到时拒绝执行语句
+	IgnoreTables []string                              // 不需要进行租户隔离的表名
+}
+
+func (tp *TenantPlugin) getColumn() string {
+	if tp.Column == "" {
+		return defaultTenantColumn
+	}
+	return tp.Column
+}
+
+func (tp *TenantPlugin) isIgnoreTable(tableName string) bool {
+	for _, table := range tp.IgnoreTables {
+		if table == tableName {
+			return true
+		}
+	}
+	return false
+}
+
+// 获取实体的租户字段，没有开启多租户、表被忽略或者表没有租户字段时返回nil
+func getTenantField(model any) *schema.Field {
+	tp := globalOption.Tenant
+	if tp == nil || tp.TenantId == nil {
+		return nil
+	}
+	stmt := &gorm.Statement{DB: globalDb}
+	if err := stmt.Parse(model); err != nil {
+		return nil
+	}
+	if tp.isIgnoreTable(stmt.Schema.Table) {
+		return nil
+	}
+	return stmt.Schema.LookUpField(tp.getColumn())
+}
+
+// addTenantIfNeed 添加租户条件，上下文中获取不到租户ID时返回 ErrTenantNotFound
+func addTenantIfNeed[T any](q *QueryCond[T], db *gorm.DB) error {
+	field := getTenantField(new(T))
+	// 从子查询或者公共表表达式中查询时，租户条件已经添加在子查询中
+	if field == nil || q.isFromSubQuery() {
+		return nil
+	}
+	tenantId, ok := globalOption.Tenant.TenantId(db.Statement.Context)
+	if !ok {
+		return ErrTenantNotFound
+	}
+	columnName := field.DBName
+	if q != nil && len(q.joins) > 0 {
+		columnName = field.Schema.Table + constants.Dot + columnName
+	}
+	db.Where(columnName+" "+constants.Eq+" ?", tenantId)
+	return nil
+}
+
+// fillTenantIfNeed 插入记录时使用上下文中的租户ID填充租户字段，上下文中获取不到租户ID时返回 ErrTenantNotFound
+func fillTenantIfNeed[T any](db *gorm.DB, entities ...*T) error {
+	field := getTenantField(new(T))
+	if field == nil {
+		return nil
+	}
+	tenantId, ok := globalOption.Tenant.TenantId(db.Statement.Context)
+	if !ok {
+		return ErrTenantNotFound
+	}
+	for _, entity := range entities {
+		entityValue := reflect.ValueOf(entity)
+		for entityValue.Kind() == reflect.Ptr {
+			entityValue = entityValue.Elem()
+		}
+		if err := field.Set(db.Statement.Context, entityValue, tenantId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -24,6 +24,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 type Condition struct {
@@ -66,7 +67,7 @@ func BuildQuery[T any](queryParams url.Values) *QueryCond[T] {
 	// 如果没有分组条件，直接返回默认的查询条件
 	if len(gcond) == 0 {
 		if q, ok := queryCondMap["default"]; ok {
-			q.orderBuilder = parentQuery.orderBuilder
+			q.orders = append(q.orders, parentQuery.orders...)
 			q.selectColumns = parentQuery.selectColumns
 			q.omitColumns = parentQuery.omitColumns
 			return q
@@ -75,7 +76,7 @@ func BuildQuery[T any](queryParams url.Values) *QueryCond[T] {
 		// 如果没有分组条件，但是有分组设置，返回第一个查询条件。主要为了兼容只有一个分组但是没有设置条件的情况。
 		if len(queryCondMap) == 1 {
 			for _, q := range queryCondMap {
-				q.orderBuilder = parentQuery.orderBuilder
+				q.orders = append(q.orders, parentQuery.orders...)
 				q.selectColumns = parentQuery.selectColumns
 				q.omitColumns = parentQuery.omitColumns
 				return q
@@ -206,70 +207,13 @@ func buildParentQuery[T any](conditionMap map[string]string) *QueryCond[T] {
 	return parentQuery
 }
 
+// buildGroupQuery 根据 gcond 组合分组条件，gcond 语法错误时忽略分组条件，需要校验时使用 ParseQuery
 func buildGroupQuery[T any](gcond string, queryMaps map[string]*QueryCond[T], query *QueryCond[T]) *QueryCond[T] {
-	var tempQuerys []*QueryCond[T]
-	tempQuerys = append(tempQuerys, query)
-	for i, char := range gcond {
-		str := string(char)
-		tempQuery := tempQuerys[len(tempQuerys)-1]
-		// 如果是 左括号 开头，则代表需要嵌套查询
-		if str == "(" && i != len(gcond)-1 {
-			if i != 0 && string(gcond[i-1]) == "|" {
-				tempQuery.Or(func(q *QueryCond[T]) {
-					paramQuery, isOk := queryMaps[string(gcond[i+1])]
-					if isOk {
-						q.queryExpressions = append(q.queryExpressions, paramQuery.queryExpressions...)
-						tempQuerys = append(tempQuerys, q)
-					}
-				})
-				continue
-			} else {
-				tempQuery.And(func(q *QueryCond[T]) {
-					paramQuery, isOk := queryMaps[string(gcond[i+1])]
-					if isOk {
-						q.queryExpressions = append(q.queryExpressions, paramQuery.queryExpressions...)
-						tempQuerys = append(tempQuerys, q)
-					}
-				})
-			}
-			continue
-		}
-
-		// 如果当前为 | ,而且不是最后一个字符，而且下一个字符不是 ( ,则为 or
-		if str == "|" && i != len(gcond)-1 {
-			paramQuery, isOk := queryMaps[string(gcond[i+1])]
-			if isOk {
-				tempQuery.Or().queryExpressions = append(tempQuery.queryExpressions, paramQuery.queryExpressions...)
-				tempQuery.last = paramQuery.queryExpressions[len(paramQuery.queryExpressions)-1]
-			}
-			continue
-		}
-
-		if str == "*" && i != len(gcond)-1 {
-			paramQuery, isOk := queryMaps[string(gcond[i+1])]
-			if isOk {
-				tempQuery.And()
-				tempQuery.queryExpressions = append(tempQuery.queryExpressions, paramQuery.queryExpressions...)
-				tempQuery.last = paramQuery.queryExpressions[len(paramQuery.queryExpressions)-1]
-			}
-			continue
-		}
-
-		if str == ")" {
-			// 删除最后一个query对象
-			tempQuerys = tempQuerys[:len(tempQuerys)-1]
-			continue
-		}
-
-		// 如果上面的条件不满足，而且是第一个的话，那么就直接添加条件
-		if i == 0 {
-			paramQuery, isOk := queryMaps[string(gcond[i])]
-			if isOk {
-				tempQuery.queryExpressions = append(tempQuery.queryExpressions, paramQuery.queryExpressions...)
-				tempQuery.last = paramQuery.queryExpressions[len(paramQuery.queryExpressions)-1]
-			}
-		}
+	node, err := parseGcond(gcond)
+	if err != nil {
+		return query
 	}
+	lowerGcond(query, node, "", queryMaps)
 	return query
 }
 
@@ -335,7 +279,7 @@ func LikeRight(query *QueryCond[any], name string, value any) {
 }
 
 func notIn(query *QueryCond[any], name string, value any) {
-	values := strings.Split(fmt.Sprintf("%s", value), ",")
+	values := splitValues(value)
 	var queryValues []any
 	for _, v := range values {
 		queryValues = append(queryValues, convert(query.columnTypeMap, name, v))
@@ -344,7 +288,7 @@ func notIn(query *QueryCond[any], name string, value any) {
 }
 
 func notBetween(query *QueryCond[any], name string, value any) {
-	values := strings.Split(fmt.Sprintf("%s", value), ",")
+	values := splitValues(value)
 	if len(values) == 2 {
 		query.NotBetween(name, convert(query.columnTypeMap, name, values[0]), convert(query.columnTypeMap, name, values[1]))
 	}
@@ -355,7 +299,7 @@ func notLike(query *QueryCond[any], name string, value any) {
 }
 
 func in(query *QueryCond[any], name string, value any) {
-	values := strings.Split(fmt.Sprintf("%s", value), ",")
+	values := splitValues(value)
 	var queryValues []any
 	for _, v := range values {
 		queryValues = append(queryValues, convert(query.columnTypeMap, name, v))
@@ -364,7 +308,7 @@ func in(query *QueryCond[any], name string, value any) {
 }
 
 func between(query *QueryCond[any], name string, value any) {
-	values := strings.Split(fmt.Sprintf("%s", value), ",")
+	values := splitValues(value)
 	if len(values) == 2 {
 		query.Between(name, convert(query.columnTypeMap, name, values[0]), convert(query.columnTypeMap, name, values[1]))
 	}
@@ -406,14 +350,44 @@ func lt(query *QueryCond[any], name string, value any) {
 	query.Lt(name, convert(query.columnTypeMap, name, value))
 }
 
+// splitValues 获取 in 和 between 条件的多个值，url参数的值使用逗号分隔，JSON的数组直接使用
+func splitValues(value any) []any {
+	if values, ok := value.([]any); ok {
+		return values
+	}
+	var values []any
+	for _, v := range strings.Split(fmt.Sprintf("%s", value), ",") {
+		values = append(values, v)
+	}
+	return values
+}
+
 func convert(columnTypeMap map[string]reflect.Type, name string, value any) any {
 	columnType, ok := columnTypeMap[name]
-	if ok {
-		switch columnType.Kind() {
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			atoi, err := strconv.Atoi(fmt.Sprintf("%s", value))
-			if err == nil {
-				value = atoi
+	if !ok {
+		return value
+	}
+	if columnType.Kind() == reflect.Pointer {
+		columnType = columnType.Elem()
+	}
+	str := fmt.Sprintf("%s", value)
+	switch columnType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if atoi, err := strconv.Atoi(str); err == nil {
+			return atoi
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, err := strconv.ParseFloat(str, 64); err == nil {
+			return f
+		}
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(str); err == nil {
+			return b
+		}
+	case reflect.Struct:
+		if columnType == reflect.TypeOf(time.Time{}) {
+			if t, ok := parseTime(str); ok {
+				return t
 			}
 		}
 	}
@@ -0,0 +1,68 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gplus
+
+import (
+	"github.com/acmestack/gorm-plus/constants"
+	"gorm.io/gorm"
+	"strings"
+)
+
+// unionAlias 合并查询作为子查询时的别名
+const unionAlias = "union_query"
+
+// unionQuery 合并查询，多个查询的字段需要一致
+type unionQuery struct {
+	keyword string
+	queries []subQuery
+}
+
+func (u *unionQuery) buildSubQuery(db *gorm.DB) *gorm.DB {
+	// SQLite 不支持给 UNION 的查询加括号
+	leftBracket, rightBracket := constants.LeftBracket, constants.RightBracket
+	if db.Dialector.Name() == "sqlite" {
+		leftBracket, rightBracket = "", ""
+	}
+	var sqlBuilder strings.Builder
+	args := make([]any, len(u.queries))
+	for i, query := range u.queries {
+		if i > 0 {
+			sqlBuilder.WriteString(" " + u.keyword + " ")
+		}
+		sqlBuilder.WriteString(leftBracket + "?" + rightBracket)
+		args[i] = query.buildSubQuery(db)
+	}
+	return db.Raw(sqlBuilder.String(), args...)
+}
+
+// Union 合并多个查询的结果并去重，返回从合并结果中查询的条件，可以继续添加条件、排序、分页，例如：
+// Union[UserVo](q1, q2).OrderByDesc("created_at").Limit(10)
+// 合并的查询需要查询相同的字段，外层查询使用字段名称字符串或者 T 的字段指针
+func Union[T any](queries ...subQuery) *QueryCond[T] {
+	return newUnionQuery[T](constants.Union, queries)
+}
+
+// UnionAll 合并多个查询的结果，不去重
+func UnionAll[T any](queries ...subQuery) *QueryCond[T] {
+	return newUnionQuery[T](constants.UnionAll, queries)
+}
+
+func newUnionQuery[T any](keyword string, queries []subQuery) *QueryCond[T] {
+	q, _ := NewQuery[T]()
+	return q.From(&unionQuery{keyword: keyword, queries: queries}, unionAlias)
+}
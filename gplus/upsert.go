@@ -0,0 +1,199 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gplus
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrTenantConflict 开启多租户时，MySQL 冲突更新的冲突字段没有包含租户字段，可能覆盖其他租户的记录
+var ErrTenantConflict = errors.New("gplus: upsert conflict columns must include the tenant column")
+
+// Conflict 插入冲突时的处理方式，基于 gorm 的 clause.OnConflict 实现，兼容 MySQL、Postgres、SQLite
+type Conflict struct {
+	columns       []any
+	updateColumns []any
+	doNothing     bool
+}
+
+// OnConflict 设置冲突字段，没有设置冲突时的处理方式时，默认更新除主键以外的所有字段
+// Tips: MySQL 会忽略冲突字段，根据主键和唯一索引判断冲突
+// 开启多租户时，MySQL 冲突更新需要在冲突字段中包含租户字段，表示唯一索引包含租户字段，否则返回 ErrTenantConflict
+func OnConflict(columns ...any) *Conflict {
+	return &Conflict{columns: columns}
+}
+
+// DoUpdates 冲突时只更新指定的字段
+func (c *Conflict) DoUpdates(columns ...any) *Conflict {
+	c.updateColumns = append(c.updateColumns, columns...)
+	return c
+}
+
+// DoNothing 冲突时不做任何处理
+func (c *Conflict) DoNothing() *Conflict {
+	c.doNothing = true
+	return c
+}
+
+// buildClause 构建冲突子句，excludeColumns 为更新全部字段时需要排除的字段，例如租户字段和版本号字段
+func (c *Conflict) buildClause(db *gorm.DB, model any, excludeColumns []string) clause.OnConflict {
+	var onConflict clause.OnConflict
+	if c == nil {
+		buildUpdateAll(&onConflict, db, model, excludeColumns)
+		return onConflict
+	}
+	for _, column := range c.columns {
+		onConflict.Columns = append(onConflict.Columns, clause.Column{Name: getColumnName(column)})
+	}
+	if c.doNothing {
+		onConflict.DoNothing = true
+		return onConflict
+	}
+	if len(c.updateColumns) > 0 {
+		var columnNames []string
+		for _, column := range c.updateColumns {
+			columnNames = append(columnNames, getColumnName(column))
+		}
+		onConflict.DoUpdates = clause.AssignmentColumns(columnNames)
+		return onConflict
+	}
+	buildUpdateAll(&onConflict, db, model, excludeColumns)
+	return onConflict
+}
+
+// buildUpdateAll 冲突时更新除主键以外的所有字段，存在需要排除的字段时，按照 gorm UpdateAll 的规则逐个生成更新字段
+func buildUpdateAll(onConflict *clause.OnConflict, db *gorm.DB, model any, excludeColumns []string) {
+	if len(excludeColumns) == 0 {
+		onConflict.UpdateAll = true
+		return
+	}
+	stmt := &gorm.Statement{DB: db, Selects: db.Statement.Selects, Omits: db.Statement.Omits}
+	if err := stmt.Parse(model); err != nil {
+		db.AddError(err)
+		return
+	}
+	selectColumns, restricted := stmt.SelectAndOmitColumns(true, true)
+	var columnNames []string
+	for _, field := range stmt.Schema.Fields {
+		if field.DBName == "" || !field.Creatable || field.PrimaryKey || field.AutoCreateTime > 0 ||
+			(field.HasDefaultValue && field.DefaultValueInterface == nil) || containsString(excludeColumns, field.DBName) {
+			continue
+		}
+		if v, ok := selectColumns[field.DBName]; (ok && v) || (!ok && !restricted) {
+			columnNames = append(columnNames, field.DBName)
+		}
+	}
+	if len(columnNames) == 0 {
+		onConflict.DoNothing = true
+		return
+	}
+	onConflict.DoUpdates = clause.AssignmentColumns(columnNames)
+}
+
+// getUpsertExcludeColumns 冲突更新时不能被覆盖的字段：租户字段和乐观锁版本号字段
+func getUpsertExcludeColumns[T any]() []string {
+	var columns []string
+	if field := getTenantField(new(T)); field != nil {
+		columns = append(columns, field.DBName)
+	}
+	if vf := getVersionField[T](); vf != nil {
+		columns = append(columns, vf.columnName)
+	}
+	return columns
+}
+
+// addTenantConflictIfNeed 开启多租户时，冲突更新只能更新当前租户的记录
+// Postgres、SQLite 在冲突更新时添加租户条件，MySQL 不支持条件，冲突字段中需要包含租户字段
+func addTenantConflictIfNeed[T any](db *gorm.DB, onConflict *clause.OnConflict) error {
+	field := getTenantField(new(T))
+	if field == nil || onConflict.DoNothing || (!onConflict.UpdateAll && len(onConflict.DoUpdates) == 0) {
+		return nil
+	}
+	if db.Dialector.Name() == "mysql" {
+		for _, column := range onConflict.Columns {
+			if column.Name == field.DBName {
+				return nil
+			}
+		}
+		return ErrTenantConflict
+	}
+	tenantId, ok := globalOption.Tenant.TenantId(db.Statement.Context)
+	if !ok {
+		return ErrTenantNotFound
+	}
+	onConflict.Where.Exprs = append(onConflict.Where.Exprs,
+		clause.Eq{Column: clause.Column{Table: field.Schema.Table, Name: field.DBName}, Value: tenantId})
+	return nil
+}
+
+// Upsert 插入一条记录，冲突时根据 conflict 更新记录或者不做处理
+func Upsert[T any](entity *T, conflict *Conflict, opts ...OptionFunc) *gorm.DB {
+	db := getDb(opts...)
+	if err := fillTenantIfNeed(db, entity); err != nil {
+		db.AddError(err)
+		return db
+	}
+	if err := fillInsertIfNeed(db, entity); err != nil {
+		db.AddError(err)
+		return db
+	}
+	restore, err := encryptEntitiesIfNeed(db, entity)
+	if err != nil {
+		db.AddError(err)
+		return db
+	}
+	defer restore()
+	onConflict := conflict.buildClause(db, new(T), getUpsertExcludeColumns[T]())
+	if err = addTenantConflictIfNeed[T](db, &onConflict); err != nil {
+		db.AddError(err)
+		return db
+	}
+	resultDb := db.Clauses(onConflict).Create(entity)
+	return resultDb
+}
+
+// UpsertBatch 批量插入多条记录，冲突时根据 conflict 更新记录或者不做处理
+func UpsertBatch[T any](entities []*T, conflict *Conflict, opts ...OptionFunc) *gorm.DB {
+	db := getDb(opts...)
+	if len(entities) == 0 {
+		return db
+	}
+	if err := fillTenantIfNeed(db, entities...); err != nil {
+		db.AddError(err)
+		return db
+	}
+	if err := fillInsertIfNeed(db, entities...); err != nil {
+		db.AddError(err)
+		return db
+	}
+	restore, err := encryptEntitiesIfNeed(db, entities...)
+	if err != nil {
+		db.AddError(err)
+		return db
+	}
+	defer restore()
+	onConflict := conflict.buildClause(db, new(T), getUpsertExcludeColumns[T]())
+	if err = addTenantConflictIfNeed[T](db, &onConflict); err != nil {
+		db.AddError(err)
+		return db
+	}
+	resultDb := db.Clauses(onConflict).CreateInBatches(entities, defaultBatchSize)
+	return resultDb
+}
@@ -0,0 +1,348 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gplus
+
+import (
+	"errors"
+	"fmt"
+	"github.com/acmestack/gorm-plus/constants"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrValuesNotSupported 查询条件无法转换为url参数，例如子查询、字段比较、连表、分组等，避免生成丢失条件的url参数
+var ErrValuesNotSupported = errors.New("gplus: query can not be encoded to url values")
+
+// sql关键字对应的url参数操作符
+var keywordOperators = map[string]string{
+	constants.Eq:                            "=",
+	constants.Ne:                            "!=",
+	constants.Gt:                            ">",
+	constants.Ge:                            ">=",
+	constants.Lt:                            "<",
+	constants.Le:                            "<=",
+	constants.In:                            "?=",
+	constants.Not + " " + constants.In:      "!?=",
+	constants.Between:                       "^=",
+	constants.Not + " " + constants.Between: "!^=",
+	constants.Like:                          "~=",
+	constants.Not + " " + constants.Like:    "!~=",
+	constants.IsNull:                        "=",
+	constants.IsNotNull:                     "!=",
+}
+
+// ToValues 将查询条件转换为 BuildQuery 可以解析的url参数，包括 q、gcond、sort、select、omit，
+// 条件中包含 OR 或者嵌套条件时，每个条件单独作为一个分组，通过 gcond 还原条件之间的关系
+// Tips: 子查询、字段比较、连表、分组、Limit 等url参数无法表达的条件，以及包含逗号（IN、BETWEEN）或者操作符等无法还原的值，
+// 返回 ErrValuesNotSupported，不会忽略条件
+func (q *QueryCond[T]) ToValues() (url.Values, error) {
+	if err := q.checkValuesSupported(); err != nil {
+		return nil, err
+	}
+	values := url.Values{}
+	encoder := &valuesEncoder{}
+	gcond := encoder.encode(q.queryExpressions)
+	if encoder.err != nil {
+		return nil, encoder.err
+	}
+	if encoder.grouped {
+		values["q"] = encoder.groupConditions()
+		values.Set("gcond", gcond)
+	} else if len(encoder.conditions) > 0 {
+		values["q"] = encoder.conditions
+	}
+
+	if len(q.orders) > 0 {
+		var sorts []string
+		for _, order := range q.orders {
+			column := getColumnName(order.column)
+			if strings.ContainsAny(column, "(),.`") {
+				return nil, fmt.Errorf("%w: order by %s", ErrValuesNotSupported, column)
+			}
+			if order.orderType == constants.Desc {
+				column = "-" + column
+			}
+			sorts = append(sorts, column)
+		}
+		values.Set("sort", strings.Join(sorts, ","))
+	}
+	if len(q.selectColumns) > 0 {
+		selectColumns, _ := buildColumns(q.selectColumns, false)
+		values.Set("select", strings.Join(selectColumns, ","))
+	}
+	if len(q.omitColumns) > 0 {
+		omitColumns, _ := buildColumns(q.omitColumns, false)
+		values.Set("omit", strings.Join(omitColumns, ","))
+	}
+	return values, nil
+}
+
+// Encode 将查询条件编码为url查询字符串，无法转换时返回 ErrValuesNotSupported
+func (q *QueryCond[T]) Encode() (string, error) {
+	values, err := q.ToValues()
+	if err != nil {
+		return "", err
+	}
+	return values.Encode(), nil
+}
+
+// 检查url参数无法表达的查询设置
+func (q *QueryCond[T]) checkValuesSupported() error {
+	_, selectArgs := buildColumns(q.selectColumns, false)
+	_, orderArgs := buildOrderSql(q.orders, false)
+	var unsupported string
+	switch {
+	case len(q.joins) > 0:
+		unsupported = "join"
+	case len(q.ctes) > 0 || q.fromQuery != nil || q.fromTable != "":
+		unsupported = "from"
+	case len(q.distinctColumns) > 0:
+		unsupported = "distinct"
+	case len(selectArgs) > 0 || len(orderArgs) > 0:
+		unsupported = "function"
+	case len(q.groupColumns) > 0:
+		unsupported = "group by"
+	case q.havingBuilder.Len() > 0 || len(q.havingConds) > 0:
+		unsupported = "having"
+	case q.limit != nil || q.offset > 0:
+		unsupported = "limit"
+	}
+	if unsupported != "" {
+		return fmt.Errorf("%w: %s", ErrValuesNotSupported, unsupported)
+	}
+	return nil
+}
+
+type valuesEncoder struct {
+	conditions []string // 按顺序记录的条件，例如：username=afumu
+	grouped    bool     // 是否需要通过 gcond 组合条件
+	err        error    // 第一个无法转换的条件
+}
+
+// 每个条件单独作为一个分组，分组名称为 g + 序号
+func (e *valuesEncoder) groupConditions() []string {
+	conditions := make([]string, len(e.conditions))
+	for i, condition := range e.conditions {
+		conditions[i] = e.groupName(i) + constants.Dot + condition
+	}
+	return conditions
+}
+
+func (e *valuesEncoder) groupName(index int) string {
+	return "g" + strconv.Itoa(index+1)
+}
+
+// encode 遍历查询表达式，记录条件并返回条件之间关系的 gcond 表达式
+func (e *valuesEncoder) encode(expressions []any) string {
+	var builder strings.Builder
+	connector := "*"
+	negate := false
+	addTerm := func(term string) {
+		if term == "" {
+			return
+		}
+		if negate {
+			term = "!" + term
+			negate = false
+		}
+		if builder.Len() > 0 {
+			builder.WriteString(connector)
+		}
+		builder.WriteString(term)
+		connector = "*"
+	}
+
+	for i := 0; i < len(expressions); i++ {
+		switch segment := expressions[i].(type) {
+		case *sqlKeyword:
+			switch segment.keyword {
+			case constants.And:
+				connector = "*"
+			case constants.Or:
+				connector = "|"
+				e.grouped = true
+			case constants.Not:
+				negate = true
+				e.grouped = true
+			default:
+				e.setError(fmt.Errorf("%w: %s", ErrValuesNotSupported, segment.keyword))
+			}
+		case *columnPointer:
+			// 条件由字段、关键字和值组成，直到下一个 AND、OR 关键字或者嵌套条件
+			end := i + 1
+			for end < len(expressions) && !isConditionBoundary(expressions[end]) {
+				end++
+			}
+			condition, err := encodeCondition(segment, expressions[i+1:end])
+			i = end - 1
+			if err != nil {
+				e.setError(err)
+				continue
+			}
+			e.conditions = append(e.conditions, condition)
+			addTerm(e.groupName(len(e.conditions) - 1))
+		case interface{ getExpressions() []any }:
+			inner := e.encode(segment.getExpressions())
+			if inner != "" {
+				e.grouped = true
+				addTerm(constants.LeftBracket + inner + constants.RightBracket)
+			}
+		default:
+			e.setError(ErrValuesNotSupported)
+		}
+	}
+	return builder.String()
+}
+
+func (e *valuesEncoder) setError(err error) {
+	if e.err == nil {
+		e.err = err
+	}
+}
+
+func isConditionBoundary(expression any) bool {
+	if keyword, ok := expression.(*sqlKeyword); ok {
+		return keyword.keyword == constants.And || keyword.keyword == constants.Or || keyword.keyword == constants.Not
+	}
+	_, isColumn := expression.(*columnPointer)
+	_, isNested := expression.(interface{ getExpressions() []any })
+	return isColumn || isNested
+}
+
+// 将单个条件转换为url参数格式，无法表达或者无法还原的条件返回 ErrValuesNotSupported
+func encodeCondition(column *columnPointer, segments []any) (string, error) {
+	columnName := getColumnName(column.column)
+	if column.qualified || len(segments) == 0 || columnName == "" {
+		return "", fmt.Errorf("%w: column %v", ErrValuesNotSupported, column.column)
+	}
+	keyword, ok := segments[0].(*sqlKeyword)
+	if !ok {
+		return "", fmt.Errorf("%w: condition on %s", ErrValuesNotSupported, columnName)
+	}
+	op, ok := keywordOperators[keyword.keyword]
+	if !ok {
+		return "", fmt.Errorf("%w: %s %s", ErrValuesNotSupported, columnName, keyword.keyword)
+	}
+
+	var values []string
+	for _, segment := range segments[1:] {
+		cv, isValue := segment.(*columnValue)
+		if !isValue {
+			return "", fmt.Errorf("%w: %s %s subquery or column", ErrValuesNotSupported, columnName, keyword.keyword)
+		}
+		if cv.value == constants.And && strings.HasSuffix(keyword.keyword, constants.Between) {
+			continue
+		}
+		formatted, err := formatValues(cv.value)
+		if err != nil {
+			return "", fmt.Errorf("%w: %s %s %v", err, columnName, keyword.keyword, cv.value)
+		}
+		values = append(values, formatted...)
+	}
+
+	var value string
+	switch keyword.keyword {
+	case constants.IsNull, constants.IsNotNull:
+		value = "null"
+	case constants.Like, constants.Not + " " + constants.Like:
+		if len(values) != 1 {
+			return "", fmt.Errorf("%w: %s %s", ErrValuesNotSupported, columnName, keyword.keyword)
+		}
+		op, value = encodeLike(op, values[0])
+	case constants.Eq, constants.Ne:
+		// 值为 null 时会被解析为 IS NULL、IS NOT NULL
+		value = strings.Join(values, ",")
+		if strings.EqualFold(value, "null") {
+			return "", fmt.Errorf("%w: %s %s %s", ErrValuesNotSupported, columnName, keyword.keyword, value)
+		}
+	default:
+		// IN、BETWEEN 的值使用逗号分隔，值中包含逗号时无法还原
+		for _, v := range values {
+			if strings.Contains(v, ",") {
+				return "", fmt.Errorf("%w: %s %s %s", ErrValuesNotSupported, columnName, keyword.keyword, v)
+			}
+		}
+		value = strings.Join(values, ",")
+	}
+	condition := columnName + op + value
+	// 值中包含优先匹配的其他操作符时，解析得到的操作符不一致
+	if getCurrentOp(condition) != op {
+		return "", fmt.Errorf("%w: %s %s %s", ErrValuesNotSupported, columnName, keyword.keyword, value)
+	}
+	return condition, nil
+}
+
+// 根据 % 的位置还原 LIKE 的操作符
+func encodeLike(op string, value string) (string, string) {
+	hasPrefix := strings.HasPrefix(value, "%")
+	hasSuffix := len(value) > 1 && strings.HasSuffix(value, "%")
+	switch {
+	case hasPrefix && hasSuffix:
+		return op, value[1 : len(value)-1]
+	case hasPrefix:
+		return strings.TrimSuffix(op, "=") + "<=", value[1:]
+	case hasSuffix:
+		return strings.TrimSuffix(op, "=") + ">=", value[:len(value)-1]
+	}
+	return op, value
+}
+
+// 格式化条件的值，切片的每个元素单独格式化
+func formatValues(value any) ([]string, error) {
+	valueOf := reflect.ValueOf(value)
+	if valueOf.Kind() == reflect.Slice && valueOf.Type().Elem().Kind() != reflect.Uint8 {
+		values := make([]string, valueOf.Len())
+		for i := 0; i < valueOf.Len(); i++ {
+			v, err := formatValue(valueOf.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+		return values, nil
+	}
+	v, err := formatValue(value)
+	if err != nil {
+		return nil, err
+	}
+	return []string{v}, nil
+}
+
+// 格式化单个值，使用 BuildQuery 能够还原的格式，时间使用 RFC3339Nano，其他类型无法还原时返回 ErrValuesNotSupported
+func formatValue(value any) (string, error) {
+	if t, ok := value.(time.Time); ok {
+		return t.Format(time.RFC3339Nano), nil
+	}
+	valueOf := reflect.ValueOf(value)
+	switch valueOf.Kind() {
+	case reflect.String:
+		return valueOf.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(valueOf.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(valueOf.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(valueOf.Float(), 'f', -1, valueOf.Type().Bits()), nil
+	case reflect.Bool:
+		return strconv.FormatBool(valueOf.Bool()), nil
+	}
+	return "", ErrValuesNotSupported
+}
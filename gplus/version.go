@@ -0,0 +1,105 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gplus
+
+import (
+	"errors"
+	"github.com/acmestack/gorm-plus/constants"
+	"gorm.io/gorm"
+	"reflect"
+	"sync"
+)
+
+// ErrOptimisticLock 乐观锁更新失败，记录已经被其他人修改
+var ErrOptimisticLock = errors.New("gplus: optimistic lock failed, the record has been modified")
+
+const versionTag = "VERSION"
+
+type versionField struct {
+	columnName string
+	fieldName  string
+}
+
+// 缓存实体的乐观锁版本号字段，key为实体类型，value为版本号字段，没有配置时value为nil
+var versionFieldCache sync.Map
+
+// 获取实体中设置了 gplus:"version" 标签的版本号字段，版本号字段只支持整数类型
+func getVersionField[T any]() *versionField {
+	modelTypeStr := reflect.TypeOf((*T)(nil)).Elem().String()
+	if value, ok := versionFieldCache.Load(modelTypeStr); ok {
+		return value.(*versionField)
+	}
+	var vf *versionField
+	modelType := reflect.TypeOf((*T)(nil)).Elem()
+	if modelType.Kind() == reflect.Struct {
+		if field, _, ok := lookUpTagField(modelType, versionTag); ok && isIntegerKind(field.Type.Kind()) {
+			vf = &versionField{columnName: parseColumnName(field), fieldName: field.Name}
+		}
+	}
+	versionFieldCache.Store(modelTypeStr, vf)
+	return vf
+}
+
+// updateWithVersion 乐观锁更新：SET version = 旧版本号 + 1 WHERE version = 旧版本号
+// 更新成功后实体中的版本号为新的版本号，更新失败时恢复为旧的版本号
+func updateWithVersion[T any](db *gorm.DB, entity *T, vf *versionField) *gorm.DB {
+	versionValue := reflect.ValueOf(entity).Elem().FieldByName(vf.fieldName)
+	oldVersion := reflect.ValueOf(versionValue.Interface())
+	increaseVersion(versionValue)
+	resultDb := db.Model(entity).Where(vf.columnName+" "+constants.Eq+" ?", oldVersion.Interface()).Updates(entity)
+	if resultDb.Error != nil || resultDb.RowsAffected == 0 {
+		versionValue.Set(oldVersion)
+		if resultDb.Error == nil && !resultDb.DryRun {
+			resultDb.AddError(ErrOptimisticLock)
+		}
+	}
+	return resultDb
+}
+
+// 版本号加一
+func increaseVersion(versionValue reflect.Value) {
+	switch versionValue.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		versionValue.SetInt(versionValue.Int() + 1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		versionValue.SetUint(versionValue.Uint() + 1)
+	}
+}
+
+// 判断条件中是否存在 版本号 = 值 的条件，存在时条件更新同样需要进行乐观锁校验
+func containsEqColumn(expressions []any, columnName string) bool {
+	for i := 0; i < len(expressions)-1; i++ {
+		cp, isColumn := expressions[i].(*columnPointer)
+		if !isColumn || getColumnName(cp.column) != columnName {
+			continue
+		}
+		if keyword, isKeyword := expressions[i+1].(*sqlKeyword); isKeyword && keyword.keyword == constants.Eq {
+			return true
+		}
+	}
+	return false
+}
+
+func isIntegerKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
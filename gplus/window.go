@@ -0,0 +1,129 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gplus
+
+import (
+	"github.com/acmestack/gorm-plus/constants"
+	"strconv"
+	"strings"
+)
+
+// Window 窗口定义：PARTITION BY 字段1,字段2 ORDER BY 字段3 DESC
+type Window struct {
+	partitionColumns []string
+	partitionArgs    []any
+	orderBuilder     strings.Builder
+	orderArgs        []any
+}
+
+// PartitionBy 按字段分区，返回窗口定义，可以继续指定排序，不需要分区时不传字段
+func PartitionBy(columns ...any) *Window {
+	w := &Window{}
+	for _, column := range columns {
+		columnStr, args := buildFunArg(column)
+		w.partitionColumns = append(w.partitionColumns, columnStr)
+		w.partitionArgs = append(w.partitionArgs, args...)
+	}
+	return w
+}
+
+// OrderByAsc 窗口内排序：ORDER BY 字段1,字段2 ASC
+func (w *Window) OrderByAsc(columns ...any) *Window {
+	w.buildOrder(constants.Asc, columns...)
+	return w
+}
+
+// OrderByDesc 窗口内排序：ORDER BY 字段1,字段2 DESC
+func (w *Window) OrderByDesc(columns ...any) *Window {
+	w.buildOrder(constants.Desc, columns...)
+	return w
+}
+
+func (w *Window) buildOrder(orderType string, columns ...any) {
+	for _, column := range columns {
+		if w.orderBuilder.Len() > 0 {
+			w.orderBuilder.WriteString(constants.Comma)
+		}
+		columnStr, args := buildFunArg(column)
+		w.orderBuilder.WriteString(columnStr + " " + orderType)
+		w.orderArgs = append(w.orderArgs, args...)
+	}
+}
+
+func (w *Window) build() (string, []any) {
+	if w == nil {
+		return "", nil
+	}
+	var segments []string
+	var args []any
+	if len(w.partitionColumns) > 0 {
+		segments = append(segments, constants.PartitionBy+" "+strings.Join(w.partitionColumns, constants.Comma))
+		args = append(args, w.partitionArgs...)
+	}
+	if w.orderBuilder.Len() > 0 {
+		segments = append(segments, constants.OrderBy+" "+w.orderBuilder.String())
+		args = append(args, w.orderArgs...)
+	}
+	return strings.Join(segments, " "), args
+}
+
+// Over 将函数作为窗口函数，例如：Sum(&u.Score).Over(PartitionBy(&u.Dept).OrderByAsc(&u.ID))
+// 生成 SUM(score) OVER (PARTITION BY dept ORDER BY id ASC)，窗口为 nil 时生成 OVER ()
+func (f *Function) Over(w *Window) *Function {
+	funStr, args := buildFunArg(f)
+	windowStr, windowArgs := w.build()
+	return newFunction(funStr+" "+constants.OVER+" "+constants.LeftBracket+windowStr+constants.RightBracket, append(args, windowArgs...)...)
+}
+
+// RowNumber 行号 ROW_NUMBER()，需要配合 Over 使用
+func RowNumber() *Function {
+	return newFunction(constants.ROW_NUMBER + constants.LeftBracket + constants.RightBracket)
+}
+
+// Rank 排名 RANK()，相同值排名相同，后续排名跳过，需要配合 Over 使用
+func Rank() *Function {
+	return newFunction(constants.RANK + constants.LeftBracket + constants.RightBracket)
+}
+
+// DenseRank 排名 DENSE_RANK()，相同值排名相同，后续排名连续，需要配合 Over 使用
+func DenseRank() *Function {
+	return newFunction(constants.DENSE_RANK + constants.LeftBracket + constants.RightBracket)
+}
+
+// Lag 取窗口内前 offset 行的值 LAG(字段, offset, 默认值)，需要配合 Over 使用
+func Lag(columnName any, offset int, defaultValue ...any) *Function {
+	funStr, args := buildOffsetArgs(columnName, offset, defaultValue)
+	return newFunction(addBracket(constants.LAG, funStr), args...)
+}
+
+// Lead 取窗口内后 offset 行的值 LEAD(字段, offset, 默认值)，需要配合 Over 使用
+func Lead(columnName any, offset int, defaultValue ...any) *Function {
+	funStr, args := buildOffsetArgs(columnName, offset, defaultValue)
+	return newFunction(addBracket(constants.LEAD, funStr), args...)
+}
+
+func buildOffsetArgs(columnName any, offset int, defaultValue []any) (string, []any) {
+	funStr, args := buildFunArg(columnName)
+	funStr += constants.Comma + " " + strconv.Itoa(offset)
+	if len(defaultValue) > 0 {
+		defaultStr, defaultArgs := buildFunArg(defaultValue[0])
+		funStr += constants.Comma + " " + defaultStr
+		args = append(args, defaultArgs...)
+	}
+	return funStr, args
+}
@@ -0,0 +1,235 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"github.com/acmestack/gorm-plus/gplus"
+	"gorm.io/gorm"
+	"reflect"
+	"testing"
+)
+
+type AuditAccount struct {
+	ID       int64
+	Username string
+	Password string `gplus:"auditIgnore"`
+	Age      int
+}
+
+func (AuditAccount) TableName() string {
+	return "audit_accounts"
+}
+
+// 开启审计插件，测试结束后恢复默认配置
+func initAudit(t *testing.T, sink gplus.AuditSink, tables ...string) context.Context {
+	gplus.Init(gormDb, gplus.Audit(&gplus.AuditPlugin{
+		Operator: func(ctx context.Context) any {
+			return ctx.Value(operatorKey{})
+		},
+		Sink:   sink,
+		Tables: tables,
+	}))
+	t.Cleanup(func() {
+		gplus.Init(gormDb)
+	})
+	return context.WithValue(context.Background(), operatorKey{}, "admin")
+}
+
+func collectAudit(records *[]*gplus.AuditRecord) gplus.AuditSink {
+	return gplus.AuditFunc(func(ctx context.Context, record *gplus.AuditRecord) error {
+		*records = append(*records, record)
+		return nil
+	})
+}
+
+func checkAuditRecord(t *testing.T, records []*gplus.AuditRecord, action string, sql string, after map[string]any) {
+	if len(records) != 1 {
+		t.Fatalf("errors happened when audit expect 1 record, got %v", len(records))
+	}
+	record := records[0]
+	if record.Table != "audit_accounts" || record.Action != action || record.Operator != "admin" || record.Sql != sql {
+		t.Errorf("errors happened when audit expect: %v %v %v, got %v %v %v %v", action, sql, "admin", record.Table, record.Action, record.Sql, record.Operator)
+	}
+	if !reflect.DeepEqual(record.After, after) {
+		t.Errorf("errors happened when audit expect after: %v, got %v", after, record.After)
+	}
+	if len(record.Before) != 1 || record.RowsAffected != 1 {
+		t.Errorf("errors happened when audit expect 1 before record and 1 affected row, got %v %v", record.Before, record.RowsAffected)
+	}
+	for _, row := range record.Before {
+		if _, ok := row["password"]; ok {
+			t.Errorf("audit ignore column should not be recorded, got %v", row)
+		}
+	}
+}
+
+// 重新创建审计测试使用的记录
+func resetAuditAccount(t *testing.T) *AuditAccount {
+	if err := gormDb.AutoMigrate(&AuditAccount{}); err != nil {
+		t.Fatalf("errors happened when migrate: %v", err)
+	}
+	gormDb.Where("1 = 1").Delete(&AuditAccount{})
+	account := &AuditAccount{Username: "zhang", Password: "123456", Age: 18}
+	if err := gormDb.Create(account).Error; err != nil {
+		t.Fatalf("errors happened when create: %v", err)
+	}
+	return account
+}
+
+func TestAuditUpdate(t *testing.T) {
+	account := resetAuditAccount(t)
+	var records []*gplus.AuditRecord
+	ctx := initAudit(t, collectAudit(&records))
+	query, u := gplus.NewQuery[AuditAccount]()
+	query.Eq(&u.ID, account.ID).Set(&u.Username, "afumu").Set(&u.Password, "654321")
+	if resultDb := gplus.Update(query, gplus.Ctx(ctx)); resultDb.Error != nil {
+		t.Fatalf("errors happened when update: %v", resultDb.Error)
+	}
+	checkAuditRecord(t, records, gplus.AuditUpdate, "UPDATE `audit_accounts` SET `password`=?,`username`=? WHERE id = ?",
+		map[string]any{"username": "afumu"})
+}
+
+func TestAuditUpdateById(t *testing.T) {
+	account := resetAuditAccount(t)
+	var records []*gplus.AuditRecord
+	ctx := initAudit(t, collectAudit(&records))
+	if resultDb := gplus.UpdateById(&AuditAccount{ID: account.ID, Username: "afumu", Age: 20}, gplus.Ctx(ctx)); resultDb.Error != nil {
+		t.Fatalf("errors happened when update: %v", resultDb.Error)
+	}
+	checkAuditRecord(t, records, gplus.AuditUpdate, "UPDATE `audit_accounts` SET `username`=?,`age`=? WHERE `id` = ?",
+		map[string]any{"username": "afumu", "age": 20})
+}
+
+func TestAuditDelete(t *testing.T) {
+	account := resetAuditAccount(t)
+	var records []*gplus.AuditRecord
+	ctx := initAudit(t, collectAudit(&records))
+	if resultDb := gplus.DeleteByIds[AuditAccount]([]int64{account.ID, account.ID + 1}, gplus.Ctx(ctx)); resultDb.Error != nil {
+		t.Fatalf("errors happened when delete: %v", resultDb.Error)
+	}
+	checkAuditRecord(t, records, gplus.AuditDelete, "DELETE FROM `audit_accounts` WHERE id IN (?,?)", nil)
+}
+
+func TestAuditTables(t *testing.T) {
+	var records []*gplus.AuditRecord
+	ctx := initAudit(t, collectAudit(&records), "audit_accounts")
+	query, u := gplus.NewQuery[User]()
+	query.Eq(&u.ID, 0).Set(&u.Username, "afumu")
+	gplus.Update(query, gplus.Ctx(ctx))
+	if len(records) != 0 {
+		t.Errorf("table not in audit tables should not be audited, got %v", records)
+	}
+}
+
+func TestAuditSinkError(t *testing.T) {
+	account := resetAuditAccount(t)
+	errSink := errors.New("sink failed")
+	ctx := initAudit(t, gplus.AuditFunc(func(ctx context.Context, record *gplus.AuditRecord) error {
+		return errSink
+	}))
+	resultDb := gplus.DeleteById[AuditAccount](account.ID, gplus.Ctx(ctx))
+	if !errors.Is(resultDb.Error, errSink) {
+		t.Errorf("errors happened when audit expect: %v, got %v", errSink, resultDb.Error)
+	}
+	// 写入审计记录失败时回滚删除
+	if _, db := gplus.SelectById[AuditAccount](account.ID); db.Error != nil {
+		t.Errorf("delete should be rolled back when audit failed, got %v", db.Error)
+	}
+}
+
+func TestAuditChan(t *testing.T) {
+	account := resetAuditAccount(t)
+	records := make(chan *gplus.AuditRecord, 1)
+	ctx := initAudit(t, gplus.AuditChan(records))
+	gplus.DeleteById[AuditAccount](account.ID, gplus.Ctx(ctx))
+	checkAuditRecord(t, []*gplus.AuditRecord{<-records}, gplus.AuditDelete, "DELETE FROM `audit_accounts` WHERE `id` = ?", nil)
+}
+
+func TestAuditDryRun(t *testing.T) {
+	var records []*gplus.AuditRecord
+	ctx := initAudit(t, collectAudit(&records))
+	sessionDb := checkDeleteSql(t, "DELETE FROM `audit_accounts` WHERE `id` = 1")
+	var queries int
+	callback := sessionDb.Callback().Query().Before("gorm:query")
+	callback.Register("audit_dry_run", func(db *gorm.DB) {
+		queries++
+	})
+	defer callback.Remove("audit_dry_run")
+	gplus.DeleteById[AuditAccount](1, gplus.Db(sessionDb.WithContext(ctx)))
+	query, u := gplus.NewQuery[AuditAccount]()
+	query.Eq(&u.ID, 1).Set(&u.Username, "afumu")
+	if _, _, err := gplus.ToUpdateSQL(query, gplus.Ctx(ctx)); err != nil {
+		t.Errorf("errors happened when preview update: %v", err)
+	}
+	if len(records) != 0 || queries != 0 {
+		t.Errorf("dry run should not be audited, got %v records and %v queries", len(records), queries)
+	}
+}
+
+func TestAuditChanDryRun(t *testing.T) {
+	// 没有缓冲的通道，DryRun 写入审计记录时会一直阻塞
+	ctx := initAudit(t, gplus.AuditChan(make(chan *gplus.AuditRecord)))
+	sessionDb := gormDb.Session(&gorm.Session{DryRun: true})
+	query, u := gplus.NewQuery[AuditAccount]()
+	query.Eq(&u.ID, 1)
+	if _, _, err := gplus.ToDeleteSQL(query, gplus.Ctx(ctx)); err != nil {
+		t.Errorf("errors happened when preview delete: %v", err)
+	}
+	gplus.DeleteById[AuditAccount](1, gplus.Db(sessionDb.WithContext(ctx)))
+}
+
+func TestAuditTable(t *testing.T) {
+	account := resetAuditAccount(t)
+	initAudit(t, &gplus.AuditTable{})
+	var tables []string
+	callback := gormDb.Callback().Create().Before("gorm:create")
+	callback.Register("audit_table", func(db *gorm.DB) {
+		tables = append(tables, db.Statement.Table)
+	})
+	defer callback.Remove("audit_table")
+	gplus.DeleteById[AuditAccount](account.ID)
+	if !reflect.DeepEqual(tables, []string{"audit_log"}) {
+		t.Errorf("errors happened when audit expect write to: %v, got %v", "audit_log", tables)
+	}
+}
+
+func TestAuditMissingWhere(t *testing.T) {
+	var records []*gplus.AuditRecord
+	ctx := initAudit(t, collectAudit(&records), "audit_accounts")
+	var queries []string
+	callback := gormDb.Callback().Query().Before("gorm:query")
+	callback.Register("audit_missing_where", func(db *gorm.DB) {
+		queries = append(queries, db.Statement.Table)
+	})
+	defer callback.Remove("audit_missing_where")
+
+	query, u := gplus.NewQuery[AuditAccount]()
+	if err := gplus.Delete(query, gplus.Ctx(ctx)).Error; !errors.Is(err, gorm.ErrMissingWhereClause) {
+		t.Errorf("errors happened when audit delete expect %v, got %v", gorm.ErrMissingWhereClause, err)
+	}
+	query.Set(&u.Age, 18)
+	if err := gplus.Update(query, gplus.Ctx(ctx)).Error; !errors.Is(err, gorm.ErrMissingWhereClause) {
+		t.Errorf("errors happened when audit update expect %v, got %v", gorm.ErrMissingWhereClause, err)
+	}
+	// 拒绝执行时不查询修改前的记录，也不写入审计记录
+	if len(queries) != 0 || len(records) != 0 {
+		t.Errorf("errors happened when audit missing where, expect no query and record, got %v %v", queries, records)
+	}
+}
@@ -0,0 +1,93 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"fmt"
+	"github.com/acmestack/gorm-plus/gplus"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestQueryClone(t *testing.T) {
+	base, u := gplus.NewQuery[User]()
+	base.Eq(&u.Dept, "开发").Or(func(q *gplus.QueryCond[User]) {
+		q.Gt(&u.Age, 18).Lt(&u.Age, 30)
+	}).OrderByDesc(&u.ID).Select(&u.ID, &u.Username).Group(&u.ID)
+	baseSql, baseArgs, _ := gplus.ToSQL(base)
+
+	clone := base.Clone()
+	clone.Eq(&u.Username, "afumu").OrderByAsc(&u.Age).Select(&u.Age).Group(&u.Age)
+	cloneSql, cloneArgs, _ := gplus.ToSQL(clone)
+
+	var expectBaseSql = "SELECT `id`,`username` FROM `Users` WHERE dept = ? OR ( age > ? AND age < ? )  GROUP BY `id` ORDER BY id DESC"
+	checkPreviewSql(t, expectBaseSql, []any{"开发", 18, 30}, baseSql, baseArgs, nil)
+
+	var expectCloneSql = "SELECT `id`,`username`,`age` FROM `Users` WHERE dept = ? OR ( age > ? AND age < ? ) AND username = ?  GROUP BY id,age ORDER BY id DESC,age ASC"
+	checkPreviewSql(t, expectCloneSql, []any{"开发", 18, 30, "afumu"}, cloneSql, cloneArgs, nil)
+
+	// 修改克隆对象后，原查询条件不受影响
+	sql, args, _ := gplus.ToSQL(base)
+	checkPreviewSql(t, expectBaseSql, []any{"开发", 18, 30}, sql, args, nil)
+}
+
+func TestQueryCloneUpdate(t *testing.T) {
+	base, u := gplus.NewQuery[User]()
+	base.Eq(&u.ID, 1).Set(&u.Score, 100)
+	clone := base.Clone().Set(&u.Address, "shanghai")
+
+	sql, args, _ := gplus.ToUpdateSQL(base, gplus.Omit(&u.UpdatedAt))
+	checkPreviewSql(t, "UPDATE `Users` SET `score`=? WHERE id = ?", []any{100, 1}, sql, args, nil)
+	sql, args, _ = gplus.ToUpdateSQL(clone, gplus.Omit(&u.UpdatedAt))
+	checkPreviewSql(t, "UPDATE `Users` SET `address`=?,`score`=? WHERE id = ?", []any{"shanghai", 100, 1}, sql, args, nil)
+}
+
+func TestQueryConcurrentRender(t *testing.T) {
+	base, u := gplus.NewQuery[User]()
+	base.Eq(&u.Dept, "开发").And(func(q *gplus.QueryCond[User]) {
+		q.Like(&u.Username, "zhang").Or().IsNull(&u.Phone)
+	}).OrderByDesc(&u.ID)
+	expectSql, expectArgs, _ := gplus.ToSQL(base)
+
+	var wg sync.WaitGroup
+	errs := make(chan string, 100)
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			sql, args, err := gplus.ToSQL(base)
+			if err != nil || sql != expectSql || !reflect.DeepEqual(args, expectArgs) {
+				errs <- fmt.Sprintf("base: %v %v %v", sql, args, err)
+			}
+		}()
+		go func(age int) {
+			defer wg.Done()
+			clone := base.Clone().Eq(&u.Age, age)
+			_, args, err := gplus.ToSQL(clone)
+			if err != nil || !reflect.DeepEqual(args, append(append([]any{}, expectArgs...), age)) {
+				errs <- fmt.Sprintf("clone: %v %v", args, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("errors happened when render query concurrently: %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"context"
+	"github.com/acmestack/gorm-plus/gplus"
+	"gorm.io/gorm"
+	"testing"
+)
+
+type ctxKey struct{}
+
+func TestCtxOption(t *testing.T) {
+	ctx := context.WithValue(context.Background(), ctxKey{}, "afumu")
+	query, u := gplus.NewQuery[User]()
+	query.Eq(&u.Username, "afumu")
+	sessionDb := gormDb.Session(&gorm.Session{DryRun: true})
+	_, resultDb := gplus.SelectList[User](query, gplus.Db(sessionDb), gplus.Ctx(ctx))
+	if value := resultDb.Statement.Context.Value(ctxKey{}); value != "afumu" {
+		t.Errorf("context should be passed to db, expect: %v, got %v", "afumu", value)
+	}
+}
+
+func TestCtxSelectById(t *testing.T) {
+	var expectSql = "SELECT `username`,`age` FROM `Users` WHERE id = 1  LIMIT 1"
+	sessionDb := checkSelectSql(t, expectSql)
+	u := gplus.GetModel[User]()
+	gplus.SelectByIdCtx[User](context.Background(), 1, gplus.Db(sessionDb), gplus.Select(&u.Username, &u.Age))
+}
+
+func TestCtxTenantSelectList(t *testing.T) {
+	initTenant(t)
+	var expectSql = "SELECT * FROM `tenant_users` WHERE username = 'afumu'  AND tenant_id = 1"
+	sessionDb := checkSelectSql(t, expectSql)
+	query, u := gplus.NewQuery[TenantUser]()
+	query.Eq(&u.Username, "afumu")
+	gplus.SelectListCtx[TenantUser](tenantContext(1), query, gplus.Db(sessionDb))
+}
+
+func TestCtxTenantDeleteById(t *testing.T) {
+	initTenant(t)
+	var expectSql = "DELETE FROM `tenant_users` WHERE tenant_id = 2 AND `id` = 1"
+	sessionDb := checkDeleteSql(t, expectSql)
+	gplus.DeleteByIdCtx[TenantUser](tenantContext(2), 1, gplus.Db(sessionDb))
+}
+
+func TestCtxOptionsNotModified(t *testing.T) {
+	query, u := gplus.NewQuery[User]()
+	query.Eq(&u.Username, "afumu")
+	// 共用的选项切片有剩余容量时，追加的选项不能写入调用方的切片
+	opts := make([]gplus.OptionFunc, 1, 2)
+	opts[0] = gplus.Db(gormDb.Session(&gorm.Session{DryRun: true}))
+	gplus.SelectListCtx[User](context.Background(), query, opts...)
+	if opts[:2][1] != nil {
+		t.Errorf("options should not be modified by SelectListCtx")
+	}
+	if _, _, err := gplus.ToSQL(query, opts...); err != nil || opts[:2][1] != nil {
+		t.Errorf("options should not be modified by ToSQL, err: %v", err)
+	}
+}
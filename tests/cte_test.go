@@ -0,0 +1,101 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"errors"
+	"github.com/acmestack/gorm-plus/gplus"
+	"gorm.io/gorm"
+	"testing"
+)
+
+type Category struct {
+	ID       int64
+	Name     string
+	ParentId int64
+}
+
+func (Category) TableName() string {
+	return "category"
+}
+
+func TestWith(t *testing.T) {
+	adult, u := gplus.NewQuery[User]()
+	adult.Gt(&u.Age, 18)
+
+	query, _ := gplus.NewQuery[User]()
+	query.With("adult", adult).FromCte("adult").Eq(&u.Dept, "dev")
+	expect := "WITH `adult` AS (SELECT * FROM `Users` WHERE age > 18 ) SELECT * FROM `adult` WHERE dept = 'dev'"
+	checkSubQuerySql(t, expect, func(sessionDb *gorm.DB) {
+		gplus.SelectList(query, gplus.Db(sessionDb))
+	})
+
+	sql, args, err := gplus.ToCountSQL(query)
+	checkPreviewSql(t, "WITH `adult` AS (SELECT * FROM `Users` WHERE age > ? ) SELECT count(*) FROM `adult` WHERE dept = ?", []any{18, "dev"}, sql, args, err)
+}
+
+func TestWithRecursive(t *testing.T) {
+	anchor, c := gplus.NewQuery[Category]()
+	anchor.Eq(&c.ID, 1)
+
+	recursive, _ := gplus.NewQuery[Category]()
+	recursive.InnerJoin("tree", &c.ParentId, "tree.id").Select("category.*")
+
+	query, _ := gplus.NewQuery[Category]()
+	query.WithRecursive("tree", anchor, recursive).FromCte("tree").OrderByAsc(&c.ID)
+	expect := "WITH RECURSIVE `tree` AS (SELECT * FROM `category` WHERE id = 1  UNION ALL " +
+		"SELECT category.* FROM `category` INNER JOIN tree ON category.parent_id = tree.id) SELECT * FROM `tree` ORDER BY id ASC"
+	checkRowSql(t, expect, func(sessionDb *gorm.DB) {
+		gplus.SelectGeneric[Category, []Category](query, gplus.Db(sessionDb))
+	})
+}
+
+func TestWithLogicDelete(t *testing.T) {
+	undeleted, u := gplus.NewQuery[LogicUser]()
+	undeleted.Gt(&u.Age, 18)
+
+	query, _ := gplus.NewQuery[LogicUser]()
+	query.With("adult", undeleted).FromCte("adult").Eq(&u.Username, "afumu")
+	expect := "WITH `adult` AS (SELECT * FROM `logic_users` WHERE age > 18  AND deleted = 0) SELECT * FROM `adult` WHERE username = 'afumu'"
+	checkSubQuerySql(t, expect, func(sessionDb *gorm.DB) {
+		gplus.SelectList(query, gplus.Db(sessionDb))
+	})
+
+	// 没有声明的名称不是公共表表达式，仍然添加逻辑删除条件
+	query, _ = gplus.NewQuery[LogicUser]()
+	query.FromCte("logic_users_archive").Eq(&u.Username, "afumu")
+	expect = "SELECT * FROM `logic_users_archive` WHERE username = 'afumu'  AND deleted = 0"
+	checkSubQuerySql(t, expect, func(sessionDb *gorm.DB) {
+		gplus.SelectList(query, gplus.Db(sessionDb))
+	})
+}
+
+func TestWithNotSupported(t *testing.T) {
+	adult, u := gplus.NewQuery[User]()
+	adult.Gt(&u.Age, 18)
+
+	query, _ := gplus.NewQuery[User]()
+	query.With("adult", adult).FromCte("adult").Eq(&u.Dept, "dev").Set(&u.Score, 100)
+	sessionDb := gormDb.Session(&gorm.Session{DryRun: true})
+	if resultDb := gplus.Update(query, gplus.Db(sessionDb)); !errors.Is(resultDb.Error, gplus.ErrCteNotSupported) {
+		t.Errorf("errors happened when update expect: %v, got %v", gplus.ErrCteNotSupported, resultDb.Error)
+	}
+	if resultDb := gplus.Delete(query, gplus.Db(sessionDb)); !errors.Is(resultDb.Error, gplus.ErrCteNotSupported) {
+		t.Errorf("errors happened when delete expect: %v, got %v", gplus.ErrCteNotSupported, resultDb.Error)
+	}
+}
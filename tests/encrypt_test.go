@@ -0,0 +1,196 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"context"
+	"github.com/acmestack/gorm-plus/gplus"
+	"gorm.io/gorm"
+	"strings"
+	"testing"
+)
+
+type SecretUser struct {
+	ID       int64
+	Username string `gplus:"mask:name"`
+	Phone    string `gplus:"encrypt;mask:phone"`
+	Email    string `gplus:"mask=email"`
+	Password string `gplus:"encrypt;mask:password"`
+}
+
+func (SecretUser) TableName() string {
+	return "secret_users"
+}
+
+// 测试使用的加密器，方便检查生成的语句
+type prefixEncryptor struct{}
+
+func (prefixEncryptor) Encrypt(ctx context.Context, plaintext string) (string, error) {
+	return "enc:" + plaintext, nil
+}
+
+func (prefixEncryptor) Decrypt(ctx context.Context, ciphertext string) (string, error) {
+	return strings.TrimPrefix(ciphertext, "enc:"), nil
+}
+
+// 开启字段加密，测试结束后恢复默认配置
+func initEncrypt(t *testing.T) {
+	gplus.Init(gormDb, gplus.Encrypt(prefixEncryptor{}))
+	t.Cleanup(func() {
+		gplus.Init(gormDb)
+	})
+}
+
+// 模拟查询结果，DryRun 不会查询数据库
+func mockSecretUsers(sessionDb *gorm.DB) {
+	sessionDb.Callback().Query().After("gorm:query").Register("mock_secret_users", func(db *gorm.DB) {
+		record := SecretUser{ID: 1, Username: "afumu", Phone: "enc:13812345678", Email: "afumu@gmail.com", Password: "enc:123456"}
+		switch dest := db.Statement.Dest.(type) {
+		case *[]*SecretUser:
+			*dest = append(*dest, &record)
+		case *SecretUser:
+			*dest = record
+		}
+	})
+}
+
+func TestAESEncryptor(t *testing.T) {
+	encryptor := gplus.NewAESEncryptor(func(ctx context.Context) ([]byte, error) {
+		return []byte("0123456789abcdef0123456789abcdef"), nil
+	})
+	ctx := context.Background()
+	ciphertext, err := encryptor.Encrypt(ctx, "13812345678")
+	if err != nil {
+		t.Fatalf("errors happened when encrypt: %v", err)
+	}
+	again, _ := encryptor.Encrypt(ctx, "13812345678")
+	other, _ := encryptor.Encrypt(ctx, "13812345679")
+	if ciphertext != again || ciphertext == other || strings.Contains(ciphertext, "13812345678") {
+		t.Errorf("errors happened when encrypt, ciphertext should be deterministic: %v %v %v", ciphertext, again, other)
+	}
+	plaintext, err := encryptor.Decrypt(ctx, ciphertext)
+	if err != nil || plaintext != "13812345678" {
+		t.Errorf("errors happened when decrypt expect: %v, got %v %v", "13812345678", plaintext, err)
+	}
+	if _, err = encryptor.Decrypt(ctx, "invalid"); err != gplus.ErrInvalidCiphertext {
+		t.Errorf("errors happened when decrypt expect: %v, got %v", gplus.ErrInvalidCiphertext, err)
+	}
+}
+
+func TestEncryptInsert(t *testing.T) {
+	initEncrypt(t)
+	var expectSql = "INSERT INTO `secret_users` (`username`,`phone`,`email`,`password`) VALUES ('afumu','enc:13812345678','','enc:123456')"
+	sessionDb := checkInsertSql(t, expectSql)
+	user := &SecretUser{Username: "afumu", Phone: "13812345678", Password: "123456"}
+	gplus.Insert(user, gplus.Db(sessionDb))
+	if user.Phone != "13812345678" || user.Password != "123456" {
+		t.Errorf("entity should be restored after insert, got %+v", user)
+	}
+}
+
+func TestEncryptUpdate(t *testing.T) {
+	initEncrypt(t)
+	var expectSql = "UPDATE `secret_users` SET `password`='enc:654321',`username`='afumu' WHERE phone = 'enc:13812345678'"
+	sessionDb := checkUpdateSql(t, expectSql)
+	query, u := gplus.NewQuery[SecretUser]()
+	query.Eq(&u.Phone, "13812345678").Set(&u.Username, "afumu").Set(&u.Password, "654321")
+	gplus.Update(query, gplus.Db(sessionDb))
+}
+
+func TestEncryptUpdateById(t *testing.T) {
+	initEncrypt(t)
+	var expectSql = "UPDATE `secret_users` SET `phone`='enc:13812345678' WHERE `id` = 1"
+	sessionDb := checkUpdateSql(t, expectSql)
+	user := &SecretUser{ID: 1, Phone: "13812345678"}
+	gplus.UpdateById(user, gplus.Db(sessionDb))
+	if user.Phone != "13812345678" {
+		t.Errorf("entity should be restored after update, got %+v", user)
+	}
+}
+
+func TestEncryptCondition(t *testing.T) {
+	initEncrypt(t)
+	query, u := gplus.NewQuery[SecretUser]()
+	query.In(&u.Phone, []string{"13812345678", "13912345678"}).Ne(&u.Password, "123456").
+		Like(&u.Username, "afu").Or(func(q *gplus.QueryCond[SecretUser]) {
+		q.Eq(&u.Phone, "13712345678").Eq(&u.Email, "afumu@gmail.com")
+	})
+	expect := "SELECT * FROM `secret_users` WHERE phone IN (?,?) AND password <> ? AND username LIKE ? OR ( phone = ? AND email = ? )"
+	expectArgs := []any{"enc:13812345678", "enc:13912345678", "enc:123456", "%afu%", "enc:13712345678", "afumu@gmail.com"}
+	// 构建语句不修改原来的查询条件，重复构建结果一致
+	for i := 0; i < 2; i++ {
+		sql, args, err := gplus.ToSQL(query)
+		checkPreviewSql(t, expect, expectArgs, sql, args, err)
+	}
+}
+
+func TestDecryptSelect(t *testing.T) {
+	initEncrypt(t)
+	sessionDb := gormDb.Session(&gorm.Session{DryRun: true})
+	mockSecretUsers(sessionDb)
+	defer sessionDb.Callback().Query().Remove("mock_secret_users")
+
+	query, u := gplus.NewQuery[SecretUser]()
+	query.Eq(&u.ID, 1)
+	users, _ := gplus.SelectList(query, gplus.Db(sessionDb))
+	if len(users) != 1 || users[0].Phone != "13812345678" || users[0].Password != "123456" {
+		t.Errorf("errors happened when decrypt select list, got %+v", users)
+	}
+	user, _ := gplus.SelectById[SecretUser](1, gplus.Db(sessionDb))
+	if user.Phone != "13812345678" || user.Username != "afumu" {
+		t.Errorf("errors happened when decrypt select by id, got %+v", user)
+	}
+}
+
+func TestMaskSelectPage(t *testing.T) {
+	initEncrypt(t)
+	sessionDb := gormDb.Session(&gorm.Session{DryRun: true})
+	mockSecretUsers(sessionDb)
+	defer sessionDb.Callback().Query().Remove("mock_secret_users")
+
+	query, _ := gplus.NewQuery[SecretUser]()
+	page, _ := gplus.SelectPage(gplus.NewPage[SecretUser](1, 10), query, gplus.Db(sessionDb), gplus.IgnoreTotal())
+	expect := SecretUser{ID: 1, Username: "a****", Phone: "138****5678", Email: "a****@gmail.com", Password: "******"}
+	if len(page.Records) != 1 || *page.Records[0] != expect {
+		t.Errorf("errors happened when mask select page expect: %+v, got %+v", expect, page.Records)
+	}
+
+	page, _ = gplus.SelectPage(gplus.NewPage[SecretUser](1, 10), query, gplus.Db(sessionDb), gplus.IgnoreTotal(), gplus.IgnoreMask())
+	if len(page.Records) != 1 || page.Records[0].Phone != "13812345678" {
+		t.Errorf("records should not be masked when ignore mask, got %+v", page.Records)
+	}
+}
+
+func TestMaskRule(t *testing.T) {
+	gplus.RegisterMaskRule("address", func(value string) string {
+		return strings.Split(value, " ")[0] + " ***"
+	})
+	type Customer struct {
+		Name     string `gplus:"mask:name"`
+		IdCard   string `gplus:"mask:idcard"`
+		BankCard string `gplus:"mask:bankcard"`
+		Address  string `gplus:"mask:address"`
+		Remark   string
+	}
+	customer := &Customer{Name: "张三丰", IdCard: "110101199003071234", BankCard: "6222021234567890", Address: "上海 浦东新区", Remark: "vip"}
+	gplus.Mask(customer)
+	expect := Customer{Name: "张**", IdCard: "110***********1234", BankCard: "************7890", Address: "上海 ***", Remark: "vip"}
+	if *customer != expect {
+		t.Errorf("errors happened when mask expect: %+v, got %+v", expect, customer)
+	}
+}
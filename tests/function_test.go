@@ -0,0 +1,159 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"github.com/acmestack/gorm-plus/gplus"
+	"gorm.io/gorm"
+	"testing"
+)
+
+func TestFunctionSelect(t *testing.T) {
+	query, u := gplus.NewQuery[User]()
+	query.Select(
+		gplus.CountDistinct(&u.Dept).Alias("depts"),
+		gplus.Coalesce(&u.Address, gplus.Literal("unknown")).Alias("address"),
+		gplus.IfNull(&u.Score, 0).Alias("score"),
+		gplus.Concat(&u.Username, gplus.Literal("-"), &u.Dept).Alias("name"),
+		gplus.Length(gplus.Upper(&u.Username)).Alias("len"),
+		gplus.Round(gplus.Avg(&u.Score), 2).Alias("avg"),
+	)
+	sql, args, err := gplus.ToSQL(query)
+	expect := "SELECT COUNT(DISTINCT dept) AS depts,COALESCE(address, ?) AS address," +
+		"IFNULL(score, ?) AS score,CONCAT(username, ?, dept) AS name," +
+		"LENGTH(UPPER(username)) AS len,ROUND(AVG(score), 2) AS avg FROM `Users`"
+	checkPreviewSql(t, expect, []any{"unknown", 0, "-"}, sql, args, err)
+}
+
+func TestFunctionDate(t *testing.T) {
+	query, u := gplus.NewQuery[User]()
+	query.Select(gplus.DateTrunc(gplus.DateUnitMonth, &u.CreatedAt).Alias("month"), gplus.Count("*").As("total")).
+		Group(gplus.DateFormat(&u.CreatedAt, "%Y-%m"))
+	sql, args, err := gplus.ToSQL(query)
+	expect := "SELECT DATE_FORMAT(created_at, ?) AS month,COUNT(*) AS total FROM `Users`" +
+		" GROUP BY DATE_FORMAT(created_at, ?)"
+	checkPreviewSql(t, expect, []any{"%Y-%m-01", "%Y-%m"}, sql, args, err)
+}
+
+func TestFunctionCaseWhen(t *testing.T) {
+	query, u := gplus.NewQuery[User]()
+	level := gplus.Case().
+		When(&u.Age, ">=", 60, gplus.Literal("old")).
+		When(&u.Age, ">=", 18, gplus.Literal("adult")).
+		Else(gplus.Literal("it's young")).
+		End()
+	query.Select(level.Alias("level"), gplus.Sum(gplus.Case().When(&u.Score, ">", 90, 1).Else(0).End()).Alias("top"))
+	sql, args, err := gplus.ToSQL(query)
+	expect := "SELECT CASE WHEN age >= ? THEN ? WHEN age >= ? THEN ? ELSE ? END AS level," +
+		"SUM(CASE WHEN score > ? THEN ? ELSE ? END) AS top FROM `Users`"
+	checkPreviewSql(t, expect, []any{60, "old", 18, "adult", "it's young", 90, 1, 0}, sql, args, err)
+}
+
+func TestFunctionArgsOrder(t *testing.T) {
+	query, u := gplus.NewQuery[User]()
+	query.Select(&u.Dept, gplus.IfNull(&u.Score, 0).Alias("score")).
+		Eq(gplus.Coalesce(&u.Address, gplus.Literal("")), "beijing").
+		Group(&u.Dept, gplus.DateFormat(&u.CreatedAt, "%Y")).
+		Having(gplus.Sum(gplus.IfNull(&u.Score, 0)).GtValue(100)).
+		OrderByDesc(gplus.Length(&u.Username))
+	sql, args, err := gplus.ToSQL(query)
+	expect := "SELECT `dept`,IFNULL(score, ?) AS score FROM `Users` WHERE COALESCE(address, ?) = ?" +
+		"  GROUP BY dept,DATE_FORMAT(created_at, ?) HAVING SUM(IFNULL(score, ?)) > ? ORDER BY LENGTH(username) DESC"
+	checkPreviewSql(t, expect, []any{0, "", "beijing", "%Y", 0, 100}, sql, args, err)
+}
+
+// postgresDialector 只修改数据库类型的名称，用于验证函数在执行时根据语句的数据库类型生成
+type postgresDialector struct {
+	gorm.Dialector
+}
+
+func (postgresDialector) Name() string {
+	return "postgres"
+}
+
+func TestFunctionDialect(t *testing.T) {
+	query, u := gplus.NewQuery[User]()
+	query.Select(gplus.IfNull(&u.Score, 0).Alias("score"), gplus.DateTrunc(gplus.DateUnitMonth, &u.CreatedAt).Alias("month"))
+
+	db := gormDb.Session(&gorm.Session{})
+	db.Dialector = postgresDialector{Dialector: db.Dialector}
+	sql, args, err := gplus.ToSQL(query, gplus.Db(db))
+	expect := "SELECT COALESCE(score, ?) AS score,DATE_TRUNC(?, created_at) AS month FROM `Users`"
+	checkPreviewSql(t, expect, []any{0, "month"}, sql, args, err)
+
+	// 同一个查询条件使用默认的db时，按照 MySQL 生成
+	sql, args, err = gplus.ToSQL(query)
+	expect = "SELECT IFNULL(score, ?) AS score,DATE_FORMAT(created_at, ?) AS month FROM `Users`"
+	checkPreviewSql(t, expect, []any{0, "%Y-%m-01"}, sql, args, err)
+}
+
+func TestFunctionHavingAnyValue(t *testing.T) {
+	query, u := gplus.NewQuery[User]()
+	query.Select(&u.Dept, gplus.Avg(&u.Score).As("score")).Group(&u.Dept).
+		Having(gplus.Max(gplus.Lower(&u.Username)).BetweenValue("a", "m"))
+	sql, args, err := gplus.ToSQL(query)
+	expect := "SELECT `dept`,AVG(score) AS score FROM `Users` GROUP BY `dept` HAVING MAX(LOWER(username)) BETWEEN ? AND ?"
+	checkPreviewSql(t, expect, []any{"a", "m"}, sql, args, err)
+}
+
+func TestHavingCond(t *testing.T) {
+	query, u := gplus.NewQuery[User]()
+	query.Select(&u.Dept, gplus.Sum(&u.Score).As("score")).Group(&u.Dept).
+		HavingCond(func(h *gplus.QueryCond[User]) {
+			h.Gt(gplus.Sum(&u.Score), 100).Or().In(gplus.Count(&u.ID), []int{1, 2})
+		}).
+		HavingCond(func(h *gplus.QueryCond[User]) {
+			h.Between(gplus.Avg(&u.Age), 18, 30)
+		})
+	sql, args, err := gplus.ToSQL(query)
+	expect := "SELECT `dept`,SUM(score) AS score FROM `Users` GROUP BY `dept` " +
+		"HAVING (SUM(score) > ? OR COUNT(id) IN (?,?) ) AND (AVG(age) BETWEEN ? AND ? )"
+	checkPreviewSql(t, expect, []any{100, 1, 2, 18, 30}, sql, args, err)
+}
+
+func TestHavingCondWithHaving(t *testing.T) {
+	query, u := gplus.NewQuery[User]()
+	query.Select(&u.Dept).Group(&u.Dept).
+		Having(gplus.Max(&u.Age).Lt(60)).
+		HavingCond(func(h *gplus.QueryCond[User]) {
+			h.Ne(gplus.Min(&u.Username), "admin").And(func(h *gplus.QueryCond[User]) {
+				h.Ge(gplus.Count(&u.ID), 2).Or().IsNull(gplus.Max(&u.Address))
+			})
+		})
+	sql, args, err := gplus.ToSQL(query)
+	expect := "SELECT `dept` FROM `Users` GROUP BY `dept` " +
+		"HAVING MAX(age) < ? AND (MIN(username) <> ? AND ( COUNT(id) >= ? OR MAX(address) IS NULL ) )"
+	checkPreviewSql(t, expect, []any{int64(60), "admin", 2}, sql, args, err)
+}
+
+func TestFunctionCompatible(t *testing.T) {
+	_, u := gplus.NewQuery[User]()
+	sql, start, end := gplus.Count(&u.ID).Between(1, 10)
+	if sql != "COUNT(id) BETWEEN ? AND ?" || start != 1 || end != 10 {
+		t.Errorf("errors happened when function between, got %v %v %v", sql, start, end)
+	}
+	// 原有的方法返回字符串和 int64，可以直接传给 gorm
+	sql = gormDb.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(&User{}).Select(gplus.Sum(&u.Score).As("score")).Group("dept").
+			Having(gplus.Sum(&u.Score).Gt(100)).Find(&[]User{})
+	})
+	expect := "SELECT SUM(score) AS score FROM `Users` GROUP BY `dept` HAVING SUM(score) > 100"
+	if sql != expect {
+		t.Errorf("errors happened when function with gorm expect: %v, got %v", expect, sql)
+	}
+}
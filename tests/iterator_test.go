@@ -0,0 +1,109 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"errors"
+	"github.com/acmestack/gorm-plus/gplus"
+	"gorm.io/gorm"
+	"strings"
+	"testing"
+)
+
+func TestSelectEach(t *testing.T) {
+	var expectSql = "SELECT * FROM `Users` WHERE username = 'afumu'"
+	checkRowSql(t, expectSql, func(sessionDb *gorm.DB) {
+		query, u := gplus.NewQuery[User]()
+		query.Eq(&u.Username, "afumu")
+		resultDb := gplus.SelectEach(query, func(user *User) error {
+			return nil
+		}, gplus.Db(sessionDb))
+		if !errors.Is(resultDb.Error, gorm.ErrDryRunModeUnsupported) {
+			t.Errorf("errors happened when select each: %v", resultDb.Error)
+		}
+	})
+}
+
+func TestSelectIter(t *testing.T) {
+	var expectSql = "SELECT `username`,`age` FROM `Users` WHERE age > 18  ORDER BY age DESC"
+	checkRowSql(t, expectSql, func(sessionDb *gorm.DB) {
+		query, u := gplus.NewQuery[User]()
+		query.Select(&u.Username, &u.Age).Gt(&u.Age, 18).OrderByDesc(&u.Age)
+		iter, _ := gplus.SelectIter(query, gplus.Db(sessionDb))
+		defer iter.Close()
+		for iter.Next() {
+			t.Errorf("errors happened when select iter: unexpected value %v", iter.Value())
+		}
+		if !errors.Is(iter.Err(), gorm.ErrDryRunModeUnsupported) {
+			t.Errorf("errors happened when select iter: %v", iter.Err())
+		}
+	})
+}
+
+func TestSelectInChunks(t *testing.T) {
+	var expectSql = "SELECT * FROM `Users` WHERE age > 18  ORDER BY id LIMIT 100"
+	checkSubQuerySql(t, expectSql, func(sessionDb *gorm.DB) {
+		query, u := gplus.NewQuery[User]()
+		query.Gt(&u.Age, 18)
+		gplus.SelectInChunks(query, 100, func(users []*User) error {
+			return nil
+		}, gplus.Db(sessionDb))
+	})
+}
+
+func TestSelectInChunksSelect(t *testing.T) {
+	var expectSql = "SELECT `username`,`id` FROM `Users` WHERE age > 18  ORDER BY id LIMIT 100"
+	query, u := gplus.NewQuery[User]()
+	query.Select(&u.Username).Gt(&u.Age, 18)
+	checkSubQuerySql(t, expectSql, func(sessionDb *gorm.DB) {
+		gplus.SelectInChunks(query, 100, func(users []*User) error {
+			return nil
+		}, gplus.Db(sessionDb))
+	})
+
+	// 补充的主键不修改传入的查询条件
+	sql, args, err := gplus.ToSQL(query)
+	checkPreviewSql(t, "SELECT `username` FROM `Users` WHERE age > ?", []any{18}, sql, args, err)
+}
+
+func TestSelectInChunksJoin(t *testing.T) {
+	var expectSql = "SELECT `Users`.`id`,`Users`.`username`,`Users`.`password`,`Users`.`address`,`Users`.`age`,`Users`.`phone`,`Users`.`score`,`Users`.`dept`,`Users`.`created_at`,`Users`.`updated_at` FROM `Users` INNER JOIN dept ON Users.dept = dept.name WHERE dept.status = 1  ORDER BY Users.id LIMIT 1000"
+	checkSubQuerySql(t, expectSql, func(sessionDb *gorm.DB) {
+		query, u := gplus.NewQuery[User]()
+		d := gplus.GetModel[Dept]()
+		query.InnerJoin(d, &u.Dept, &d.Name).Eq(&d.Status, 1)
+		gplus.SelectInChunks(query, 0, func(users []*User) error {
+			return nil
+		}, gplus.Db(sessionDb))
+	})
+}
+
+func checkRowSql(t *testing.T, expect string, fn func(sessionDb *gorm.DB)) {
+	expect = strings.TrimSpace(expect)
+	sessionDb := gormDb.Session(&gorm.Session{DryRun: true})
+	callback := sessionDb.Callback().Row().After("gorm:row")
+	var sqls []string
+	callback.Register("print_sql", func(db *gorm.DB) {
+		sqls = append(sqls, strings.TrimSpace(buildSql(db)))
+	})
+	fn(sessionDb)
+	callback.Remove("print_sql")
+	if len(sqls) == 0 || sqls[len(sqls)-1] != expect {
+		t.Errorf("errors happened  when select expect: %v, got %v", expect, sqls)
+	}
+}
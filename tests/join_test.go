@@ -0,0 +1,105 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"github.com/acmestack/gorm-plus/gplus"
+	"gorm.io/gorm"
+	"strings"
+	"testing"
+)
+
+func TestInnerJoin(t *testing.T) {
+	var expectSql = "SELECT `Users`.`id`,`Users`.`username`,`Users`.`password`,`Users`.`address`,`Users`.`age`,`Users`.`phone`,`Users`.`score`,`Users`.`dept`,`Users`.`created_at`,`Users`.`updated_at` FROM `Users` INNER JOIN dept ON Users.dept = dept.name WHERE Users.age > 20"
+	sessionDb := checkSelectSql(t, expectSql)
+	query, u := gplus.NewQuery[User]()
+	d := gplus.GetModel[Dept]()
+	query.InnerJoin(d, &u.Dept, &d.Name).Gt(&u.Age, 20)
+	gplus.SelectList[User](query, gplus.Db(sessionDb))
+}
+
+func TestLeftJoinSelect(t *testing.T) {
+	var expectSql = "SELECT Users.username,dept.leader FROM `Users` LEFT JOIN dept ON Users.dept = dept.name WHERE Users.age > 20 AND dept.leader = 'afumu'  ORDER BY Users.id DESC"
+	type UserVo struct {
+		Username string
+		Leader   string
+	}
+	query, u := gplus.NewQuery[User]()
+	d := gplus.GetModel[Dept]()
+	query.LeftJoin(d, &u.Dept, &d.Name).
+		Select(&u.Username, &d.Leader).
+		Gt(&u.Age, 20).Eq(&d.Leader, "afumu").
+		OrderByDesc(&u.ID)
+	_, resultDb := gplus.SelectGeneric[User, []UserVo](query, gplus.Db(gormDb.Session(&gorm.Session{DryRun: true})))
+	checkResultSql(t, expectSql, resultDb)
+}
+
+func TestJoinAfterSelect(t *testing.T) {
+	var expectSql = "SELECT DISTINCT Users.id,Users.dept FROM `Users` INNER JOIN dept ON Users.dept = dept.name WHERE Users.age > 20  GROUP BY Users.id,Users.dept ORDER BY Users.id DESC"
+	type UserVo struct {
+		ID   int64
+		Dept string
+	}
+	// 连表在 Select、Distinct、Group、OrderBy 之后调用时，字段名同样带上表名
+	query, u := gplus.NewQuery[User]()
+	d := gplus.GetModel[Dept]()
+	query.Distinct(&u.ID, &u.Dept).Group(&u.ID, &u.Dept).OrderByDesc(&u.ID).
+		InnerJoin(d, &u.Dept, &d.Name).Gt(&u.Age, 20)
+	_, resultDb := gplus.SelectGeneric[User, []UserVo](query, gplus.Db(gormDb.Session(&gorm.Session{DryRun: true})))
+	checkResultSql(t, expectSql, resultDb)
+
+	expectSql = "SELECT Users.id,dept.name FROM `Users` INNER JOIN dept ON Users.dept = dept.name ORDER BY Users.id ASC"
+	query, u = gplus.NewQuery[User]()
+	query.Select(&u.ID, &d.Name).OrderByAsc(&u.ID).InnerJoin(d, &u.Dept, &d.Name)
+	_, resultDb = gplus.SelectGeneric[User, []UserVo](query, gplus.Db(gormDb.Session(&gorm.Session{DryRun: true})))
+	checkResultSql(t, expectSql, resultDb)
+}
+
+func TestRightJoinOn(t *testing.T) {
+	var expectSql = "SELECT `Users`.`id`,`Users`.`username`,`Users`.`password`,`Users`.`address`,`Users`.`age`,`Users`.`phone`,`Users`.`score`,`Users`.`dept`,`Users`.`created_at`,`Users`.`updated_at` FROM `Users` RIGHT JOIN dept ON Users.dept = dept.name AND ( dept.status = 1 ) WHERE Users.username = 'afumu'"
+	sessionDb := checkSelectSql(t, expectSql)
+	query, u := gplus.NewQuery[User]()
+	d := gplus.GetModel[Dept]()
+	query.RightJoin(d, &u.Dept, &d.Name, func(on *gplus.QueryCond[User]) {
+		on.Eq(&d.Status, 1)
+	}).Eq(&u.Username, "afumu")
+	gplus.SelectList[User](query, gplus.Db(sessionDb))
+}
+
+func TestJoinPageGeneric(t *testing.T) {
+	var expectSql = "SELECT Users.username,dept.leader FROM `Users` LEFT JOIN dept ON Users.dept = dept.name WHERE dept.status = 1  LIMIT 10"
+	type UserVo struct {
+		Username string
+		Leader   string
+	}
+	query, u := gplus.NewQuery[User]()
+	d := gplus.GetModel[Dept]()
+	query.LeftJoin(d, &u.Dept, &d.Name).Select(&u.Username, &d.Leader).Eq(&d.Status, 1)
+	page := gplus.NewPage[UserVo](1, 10)
+	_, resultDb := gplus.SelectPageGeneric[User, UserVo](page, query, gplus.Db(gormDb.Session(&gorm.Session{DryRun: true})), gplus.IgnoreTotal())
+	checkResultSql(t, expectSql, resultDb)
+}
+
+// SelectGeneric 等方法通过 Scan 查询，不会触发查询回调，所以直接校验返回的db最终生成的sql
+func checkResultSql(t *testing.T, expect string, resultDb *gorm.DB) {
+	expect = strings.TrimSpace(expect)
+	sql := strings.TrimSpace(buildSql(resultDb))
+	if sql != expect {
+		t.Errorf("errors happened  when select expect: %v, got %v", expect, sql)
+	}
+}
@@ -0,0 +1,149 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"errors"
+	"github.com/acmestack/gorm-plus/gplus"
+	"gorm.io/gorm"
+	"testing"
+)
+
+func TestQueryFromJSON(t *testing.T) {
+	data := `{
+		"and": [
+			{"field": "username", "op": "~=", "value": "zhang"},
+			{"or": [
+				{"field": "age", "op": "^=", "value": [18, 30]},
+				{"field": "score", "op": "?=", "value": ["60", 90]}
+			]},
+			{"field": "dept", "op": "=", "value": null}
+		],
+		"sort": ["-age", "username"],
+		"select": ["username", "age"]
+	}`
+	query, err := gplus.BuildQueryFromJSON[User]([]byte(data))
+	if err != nil {
+		t.Fatalf("errors happened when build query from json: %v", err)
+	}
+	var expectSql = "SELECT `username`,`age` FROM `Users` WHERE username LIKE '%zhang%' AND ( age BETWEEN 18 AND 30 OR score IN (60,90) ) AND dept IS NULL  ORDER BY age DESC,username ASC"
+	checkSubQuerySql(t, expectSql, func(sessionDb *gorm.DB) {
+		gplus.SelectList[User](query, gplus.Db(sessionDb))
+	})
+}
+
+func TestQueryFromJSONOr(t *testing.T) {
+	data := `{"or": [{"field": "username", "op": "=", "value": "afumu"}, {"and": [{"field": "age", "op": ">", "value": 18}, {"field": "age", "op": "<", "value": 30}]}]}`
+	query, err := gplus.BuildQueryFromJSON[User]([]byte(data))
+	if err != nil {
+		t.Fatalf("errors happened when build query from json: %v", err)
+	}
+	var expectSql = "SELECT * FROM `Users` WHERE username = 'afumu' OR ( age > 18 AND age < 30 )"
+	checkSubQuerySql(t, expectSql, func(sessionDb *gorm.DB) {
+		gplus.SelectList[User](query, gplus.Db(sessionDb))
+	})
+}
+
+func TestQueryFromJSONLeafAndOr(t *testing.T) {
+	data := `{"field": "dept", "op": "!=", "value": "开发", "or": [{"field": "age", "op": ">=", "value": 60}, {"field": "score", "op": "<=", "value": 10}]}`
+	query, err := gplus.BuildQueryFromJSON[User]([]byte(data))
+	if err != nil {
+		t.Fatalf("errors happened when build query from json: %v", err)
+	}
+	var expectSql = "SELECT * FROM `Users` WHERE dept <> '开发' AND ( age >= 60 OR score <= 10 )"
+	checkSubQuerySql(t, expectSql, func(sessionDb *gorm.DB) {
+		gplus.SelectList[User](query, gplus.Db(sessionDb))
+	})
+}
+
+func TestQueryFromJSONErrors(t *testing.T) {
+	tests := []struct {
+		data string
+		err  error
+	}{
+		{`{"field": "age", "op": "<>", "value": 1}`, gplus.ErrInvalidCondition},
+		{`{"and": [{"op": "=", "value": 1}]}`, gplus.ErrInvalidCondition},
+		{`{"field": "age", "op": "=", "value": {"a": 1}}`, gplus.ErrInvalidValue},
+		{`{"field": "age", "op": "=", "value": [1, 2]}`, gplus.ErrInvalidValue},
+		{`{"field": "age", "op": "^=", "value": [1, 2, 3]}`, gplus.ErrInvalidValue},
+		{`{"field": "age = 1 OR 1", "op": "=", "value": 1}`, gplus.ErrUnknownColumn},
+		{`{"sort": ["-(SELECT 1)"]}`, gplus.ErrUnknownColumn},
+		{`{"select": ["username", "password FROM users --"]}`, gplus.ErrUnknownColumn},
+		{`{"omit": ["unknown"]}`, gplus.ErrUnknownColumn},
+	}
+	for _, tt := range tests {
+		query, err := gplus.BuildQueryFromJSON[User]([]byte(tt.data))
+		if query != nil || !errors.Is(err, tt.err) {
+			t.Errorf("errors happened when build query from json %v, expect %v, got %v", tt.data, tt.err, err)
+		}
+	}
+	if _, err := gplus.BuildQueryFromJSON[User]([]byte(`{"and": `)); err == nil {
+		t.Errorf("errors happened when build query from json, expect syntax error")
+	}
+}
+
+func TestQueryFromJSONArrayValue(t *testing.T) {
+	data := `{"field": "username", "op": "?=", "value": ["zhang,san", "li"]}`
+	query, err := gplus.BuildQueryFromJSON[User]([]byte(data))
+	if err != nil {
+		t.Fatalf("errors happened when build query from json: %v", err)
+	}
+	var expectSql = "SELECT * FROM `Users` WHERE username IN ('zhang,san','li')"
+	checkSubQuerySql(t, expectSql, func(sessionDb *gorm.DB) {
+		gplus.SelectList[User](query, gplus.Db(sessionDb))
+	})
+}
+
+func TestQueryFromJSONWithPolicy(t *testing.T) {
+	u := gplus.GetModel[User]()
+	policy := &gplus.QueryPolicy{
+		FilterColumns: []any{&u.Username, &u.Age},
+		SortColumns:   []any{&u.Age},
+		SelectColumns: []any{&u.Username, &u.Age},
+		Operators:     map[any][]string{&u.Age: {">", "<"}},
+		MaxConditions: 2,
+		MaxDepth:      1,
+	}
+	data := `{"field": "username", "op": "=", "value": "afumu", "or": [{"field": "age", "op": ">", "value": 18}], "sort": ["-age"], "select": ["username"]}`
+	query, err := gplus.BuildQueryFromJSONWithPolicy[User]([]byte(data), policy)
+	if err != nil {
+		t.Fatalf("errors happened when build query from json: %v", err)
+	}
+	var expectSql = "SELECT `username` FROM `Users` WHERE username = 'afumu' AND ( age > 18 )  ORDER BY age DESC"
+	checkSubQuerySql(t, expectSql, func(sessionDb *gorm.DB) {
+		gplus.SelectList[User](query, gplus.Db(sessionDb))
+	})
+
+	tests := []struct {
+		data string
+		err  error
+	}{
+		{`{"field": "score", "op": "=", "value": 1}`, gplus.ErrColumnNotAllowed},
+		{`{"field": "age", "op": "=", "value": 1}`, gplus.ErrOperatorNotAllowed},
+		{`{"and": [{"field": "age", "op": ">", "value": 1}, {"field": "age", "op": "<", "value": 9}, {"field": "username", "op": "=", "value": "a"}]}`, gplus.ErrTooManyConditions},
+		{`{"and": [{"or": [{"and": [{"field": "age", "op": ">", "value": 1}]}]}]}`, gplus.ErrNestingTooDeep},
+		{`{"sort": ["username"]}`, gplus.ErrColumnNotAllowed},
+		{`{"omit": ["score"]}`, gplus.ErrColumnNotAllowed},
+	}
+	for _, tt := range tests {
+		query, err := gplus.BuildQueryFromJSONWithPolicy[User]([]byte(tt.data), policy)
+		if query != nil || !errors.Is(err, tt.err) {
+			t.Errorf("errors happened when build query from json %v, expect %v, got %v", tt.data, tt.err, err)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"errors"
+	"github.com/acmestack/gorm-plus/gplus"
+	"gorm.io/gorm"
+	"testing"
+)
+
+type LogicUser struct {
+	ID       int64
+	Username string
+	Age      int
+	Deleted  int `gplus:"logicDelete;deleted:1;undeleted:0"`
+}
+
+func (LogicUser) TableName() string {
+	return "logic_users"
+}
+
+type LogicDept struct {
+	ID        int64
+	Name      string
+	IsDeleted string
+}
+
+func (LogicDept) TableName() string {
+	return "logic_dept"
+}
+
+func init() {
+	d := gplus.GetModel[LogicDept]()
+	gplus.RegisterLogicDelete[LogicDept](&d.IsDeleted, "Y", "N")
+}
+
+func TestLogicDeleteById(t *testing.T) {
+	var expectSql = "UPDATE `logic_users` SET `deleted`=1 WHERE id = 1  AND deleted = 0"
+	sessionDb := checkUpdateSql(t, expectSql)
+	gplus.DeleteById[LogicUser](1, gplus.Db(sessionDb))
+}
+
+func TestLogicDeleteByIds(t *testing.T) {
+	var expectSql = "UPDATE `logic_users` SET `deleted`=1 WHERE id IN (1,2)  AND deleted = 0"
+	sessionDb := checkUpdateSql(t, expectSql)
+	gplus.DeleteByIds[LogicUser]([]int{1, 2}, gplus.Db(sessionDb))
+}
+
+func TestLogicDelete(t *testing.T) {
+	var expectSql = "UPDATE `logic_users` SET `deleted`=1 WHERE (username = 'afumu' OR age = 18 ) AND deleted = 0"
+	sessionDb := checkUpdateSql(t, expectSql)
+	query, u := gplus.NewQuery[LogicUser]()
+	query.Eq(&u.Username, "afumu").Or().Eq(&u.Age, 18)
+	gplus.Delete(query, gplus.Db(sessionDb))
+}
+
+func TestLogicDeleteRegister(t *testing.T) {
+	var expectSql = "UPDATE `logic_dept` SET `is_deleted`='Y' WHERE name = 'dev'  AND is_deleted = 'N'"
+	sessionDb := checkUpdateSql(t, expectSql)
+	query, d := gplus.NewQuery[LogicDept]()
+	query.Eq(&d.Name, "dev")
+	gplus.Delete(query, gplus.Db(sessionDb))
+}
+
+func TestLogicDeleteWithoutCondition(t *testing.T) {
+	sessionDb := gormDb.Session(&gorm.Session{DryRun: true})
+	query, u := gplus.NewQuery[LogicUser]()
+	if resultDb := gplus.Delete(query, gplus.Db(sessionDb)); !errors.Is(resultDb.Error, gorm.ErrMissingWhereClause) {
+		t.Errorf("errors happened when delete expect: %v, got %v", gorm.ErrMissingWhereClause, resultDb.Error)
+	}
+	query.Set(&u.Age, 18)
+	if resultDb := gplus.Update(query, gplus.Db(sessionDb)); !errors.Is(resultDb.Error, gorm.ErrMissingWhereClause) {
+		t.Errorf("errors happened when update expect: %v, got %v", gorm.ErrMissingWhereClause, resultDb.Error)
+	}
+	if resultDb := gplus.Restore(query, gplus.Db(sessionDb)); !errors.Is(resultDb.Error, gorm.ErrMissingWhereClause) {
+		t.Errorf("errors happened when restore expect: %v, got %v", gorm.ErrMissingWhereClause, resultDb.Error)
+	}
+}
+
+func TestLogicDeleteAllowGlobalUpdate(t *testing.T) {
+	var expectSql = "UPDATE `logic_users` SET `deleted`=1 WHERE deleted = 0"
+	sessionDb := checkUpdateSql(t, expectSql)
+	query, _ := gplus.NewQuery[LogicUser]()
+	gplus.Delete(query, gplus.Db(sessionDb.Session(&gorm.Session{AllowGlobalUpdate: true})))
+}
+
+func TestLogicDeleteSelectList(t *testing.T) {
+	var expectSql = "SELECT * FROM `logic_users` WHERE username = 'afumu'  AND deleted = 0"
+	sessionDb := checkSelectSql(t, expectSql)
+	query, u := gplus.NewQuery[LogicUser]()
+	query.Eq(&u.Username, "afumu")
+	gplus.SelectList[LogicUser](query, gplus.Db(sessionDb))
+}
+
+func TestLogicDeleteSelectById(t *testing.T) {
+	var expectSql = "SELECT * FROM `logic_users` WHERE id = 1  AND deleted = 0 LIMIT 1"
+	sessionDb := checkSelectSql(t, expectSql)
+	gplus.SelectById[LogicUser](1, gplus.Db(sessionDb))
+}
+
+func TestLogicDeleteSelectCount(t *testing.T) {
+	var expectSql = "SELECT count(*) FROM `logic_users` WHERE age > 18  AND deleted = 0"
+	sessionDb := checkSelectSql(t, expectSql)
+	query, u := gplus.NewQuery[LogicUser]()
+	query.Gt(&u.Age, 18)
+	gplus.SelectCount[LogicUser](query, gplus.Db(sessionDb))
+}
+
+func TestLogicDeleteIncludeDeleted(t *testing.T) {
+	var expectSql = "SELECT * FROM `logic_users` WHERE username = 'afumu'"
+	sessionDb := checkSelectSql(t, expectSql)
+	query, u := gplus.NewQuery[LogicUser]()
+	query.Eq(&u.Username, "afumu")
+	gplus.SelectList[LogicUser](query, gplus.Db(sessionDb), gplus.IncludeDeleted())
+}
+
+func TestLogicDeleteUpdate(t *testing.T) {
+	var expectSql = "UPDATE `logic_users` SET `age`=20 WHERE username = 'afumu'  AND deleted = 0"
+	sessionDb := checkUpdateSql(t, expectSql)
+	query, u := gplus.NewQuery[LogicUser]()
+	query.Eq(&u.Username, "afumu").Set(&u.Age, 20)
+	gplus.Update(query, gplus.Db(sessionDb))
+}
+
+func TestLogicDeleteRestore(t *testing.T) {
+	var expectSql = "UPDATE `logic_users` SET `deleted`=0 WHERE username = 'afumu'  AND deleted = 1"
+	sessionDb := checkUpdateSql(t, expectSql)
+	query, u := gplus.NewQuery[LogicUser]()
+	query.Eq(&u.Username, "afumu")
+	gplus.Restore(query, gplus.Db(sessionDb))
+}
+
+func TestLogicDeleteRestoreById(t *testing.T) {
+	var expectSql = "UPDATE `logic_users` SET `deleted`=0 WHERE id = 1  AND deleted = 1"
+	sessionDb := checkUpdateSql(t, expectSql)
+	gplus.RestoreById[LogicUser](1, gplus.Db(sessionDb))
+}
+
+func TestLogicDeleteRestoreNotConfigured(t *testing.T) {
+	query, u := gplus.NewQuery[User]()
+	query.Eq(&u.Username, "afumu")
+	resultDb := gplus.Restore(query)
+	if resultDb.Error != gplus.ErrLogicDeleteNotConfigured {
+		t.Errorf("errors happened when restore expect: %v, got %v", gplus.ErrLogicDeleteNotConfigured, resultDb.Error)
+	}
+}
+
+func TestLogicDeleteJoinTable(t *testing.T) {
+	var expectSql = "SELECT logic_users.username FROM `logic_users` LEFT JOIN logic_dept ON logic_users.username = logic_dept.name AND logic_dept.is_deleted = 'N' WHERE logic_users.age > 18  AND logic_users.deleted = 0"
+	sessionDb := checkSelectSql(t, expectSql)
+	query, u := gplus.NewQuery[LogicUser]()
+	d := gplus.GetModel[LogicDept]()
+	query.LeftJoin(d, &u.Username, &d.Name).Select(&u.Username).Gt(&u.Age, 18)
+	gplus.SelectList[LogicUser](query, gplus.Db(sessionDb))
+}
+
+func TestLogicDeleteJoinIncludeDeleted(t *testing.T) {
+	var expectSql = "SELECT logic_users.username FROM `logic_users` LEFT JOIN logic_dept ON logic_users.username = logic_dept.name WHERE logic_users.age > 18"
+	sessionDb := checkSelectSql(t, expectSql)
+	query, u := gplus.NewQuery[LogicUser]()
+	d := gplus.GetModel[LogicDept]()
+	query.LeftJoin(d, &u.Username, &d.Name).Select(&u.Username).Gt(&u.Age, 18)
+	gplus.SelectList[LogicUser](query, gplus.Db(sessionDb), gplus.IncludeDeleted())
+}
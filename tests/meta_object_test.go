@@ -0,0 +1,232 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"github.com/acmestack/gorm-plus/gplus"
+	"gorm.io/gorm"
+	"testing"
+)
+
+type AuditBase struct {
+	CreatedBy string `gplus:"fill:insert"`
+	UpdatedBy string `gplus:"fill:insertUpdate"`
+}
+
+type AuditUser struct {
+	ID       int64
+	Username string
+	AuditBase
+	Remark string `gplus:"fill:update"`
+}
+
+func (AuditUser) TableName() string {
+	return "audit_users"
+}
+
+type operatorKey struct{}
+
+type auditFillHandler struct{}
+
+func (auditFillHandler) InsertFill(ctx context.Context, meta *gplus.MetaObject) {
+	operator, _ := ctx.Value(operatorKey{}).(string)
+	meta.SetValue("CreatedBy", operator)
+	meta.SetValue("UpdatedBy", operator)
+	// 没有设置插入时填充，不会被填充
+	meta.SetValue("Remark", "inserted")
+}
+
+func (auditFillHandler) UpdateFill(ctx context.Context, meta *gplus.MetaObject) {
+	operator, _ := ctx.Value(operatorKey{}).(string)
+	meta.SetValue("CreatedBy", operator)
+	meta.SetValue("UpdatedBy", operator)
+	meta.SetValue("Remark", "updated by "+operator)
+}
+
+// 注册字段填充处理器，测试结束后恢复默认配置
+func initFillHandler(t *testing.T) context.Context {
+	gplus.Init(gormDb, gplus.FillHandler(auditFillHandler{}))
+	t.Cleanup(func() {
+		gplus.Init(gormDb)
+	})
+	return context.WithValue(context.Background(), operatorKey{}, "admin")
+}
+
+func TestFillInsert(t *testing.T) {
+	ctx := initFillHandler(t)
+	var expectSql = "INSERT INTO `audit_users` (`username`,`created_by`,`updated_by`,`remark`) VALUES ('afumu','admin','admin','')"
+	sessionDb := checkInsertSql(t, expectSql)
+	user := &AuditUser{Username: "afumu"}
+	gplus.Insert(user, gplus.Db(sessionDb.WithContext(ctx)))
+	if user.CreatedBy != "admin" || user.UpdatedBy != "admin" || user.Remark != "" {
+		t.Errorf("errors happened when fill insert, got %+v", user)
+	}
+}
+
+func TestFillInsertBatchNotOverride(t *testing.T) {
+	ctx := initFillHandler(t)
+	var expectSql = "INSERT INTO `audit_users` (`username`,`created_by`,`updated_by`,`remark`) VALUES ('afumu','admin','admin',''),('afumu2','system','admin','')"
+	sessionDb := checkInsertSql(t, expectSql)
+	users := []*AuditUser{{Username: "afumu"}, {Username: "afumu2", AuditBase: AuditBase{CreatedBy: "system"}}}
+	gplus.InsertBatch(users, gplus.Db(sessionDb.WithContext(ctx)))
+}
+
+func TestFillUpdateById(t *testing.T) {
+	ctx := initFillHandler(t)
+	var expectSql = "UPDATE `audit_users` SET `username`='afumu',`updated_by`='admin',`remark`='updated by admin' WHERE `id` = 1"
+	sessionDb := checkUpdateSql(t, expectSql)
+	user := &AuditUser{ID: 1, Username: "afumu"}
+	gplus.UpdateById(user, gplus.Db(sessionDb.WithContext(ctx)))
+	if user.CreatedBy != "" {
+		t.Errorf("created by should not be filled when update, got %v", user.CreatedBy)
+	}
+}
+
+func TestFillUpdateByIdOverwrite(t *testing.T) {
+	ctx := initFillHandler(t)
+	var expectSql = "UPDATE `audit_users` SET `username`='afumu',`created_by`='system',`updated_by`='admin',`remark`='updated by admin' WHERE `id` = 1"
+	sessionDb := checkUpdateSql(t, expectSql)
+	// 查询出来的记录已经有更新人，更新时需要覆盖为当前操作人
+	user := &AuditUser{ID: 1, Username: "afumu", AuditBase: AuditBase{CreatedBy: "system", UpdatedBy: "system"}, Remark: "old"}
+	gplus.UpdateById(user, gplus.Db(sessionDb.WithContext(ctx)))
+	if user.UpdatedBy != "admin" || user.CreatedBy != "system" {
+		t.Errorf("errors happened when fill update, got %+v", user)
+	}
+}
+
+func TestFillUpdate(t *testing.T) {
+	ctx := initFillHandler(t)
+	var expectSql = "UPDATE `audit_users` SET `remark`='updated by admin',`updated_by`='admin',`username`='afumu' WHERE id = 1"
+	sessionDb := checkUpdateSql(t, expectSql)
+	query, u := gplus.NewQuery[AuditUser]()
+	query.Eq(&u.ID, 1).Set(&u.Username, "afumu")
+	gplus.Update(query, gplus.Db(sessionDb.WithContext(ctx)))
+}
+
+func TestFillWithoutHandler(t *testing.T) {
+	var expectSql = "INSERT INTO `audit_users` (`username`,`created_by`,`updated_by`,`remark`) VALUES ('afumu','','','')"
+	sessionDb := checkInsertSql(t, expectSql)
+	gplus.Insert(&AuditUser{Username: "afumu"}, gplus.Db(sessionDb))
+}
+
+func TestFillUpdateKeepSet(t *testing.T) {
+	ctx := initFillHandler(t)
+	var expectSql = "UPDATE `audit_users` SET `remark`='updated by admin',`updated_by`='system' WHERE id = 1"
+	sessionDb := checkUpdateSql(t, expectSql)
+	query, u := gplus.NewQuery[AuditUser]()
+	query.Eq(&u.ID, 1).Set(&u.UpdatedBy, "system")
+	gplus.Update(query, gplus.Db(sessionDb.WithContext(ctx)))
+}
+
+type strictFillHandler struct {
+	auditFillHandler
+}
+
+func (strictFillHandler) UpdateFill(ctx context.Context, meta *gplus.MetaObject) {
+	meta.StrictSetValue("UpdatedBy", ctx.Value(operatorKey{}))
+}
+
+func TestFillStrictSetValue(t *testing.T) {
+	gplus.Init(gormDb, gplus.FillHandler(strictFillHandler{}))
+	t.Cleanup(func() {
+		gplus.Init(gormDb)
+	})
+	ctx := context.WithValue(context.Background(), operatorKey{}, "admin")
+	var expectSql = "UPDATE `audit_users` SET `updated_by`='admin' WHERE id = 1"
+	sessionDb := checkUpdateSql(t, expectSql)
+	query, u := gplus.NewQuery[AuditUser]()
+	query.Eq(&u.ID, 1).Set(&u.UpdatedBy, "system")
+	gplus.Update(query, gplus.Db(sessionDb.WithContext(ctx)))
+}
+
+type FillItem struct {
+	ID      int64
+	Version int64    `gplus:"fill:insert"`
+	Score   *float64 `gplus:"fill:insert"`
+	Remark  string   `gplus:"fill:insertUpdate"`
+}
+
+func (FillItem) TableName() string {
+	return "fill_items"
+}
+
+type numberFillHandler struct{}
+
+func (numberFillHandler) InsertFill(ctx context.Context, meta *gplus.MetaObject) {
+	// 数值类型之间可以转换，字段是指针时自动创建
+	meta.SetValue("Version", 1)
+	meta.SetValue("Score", 1)
+	meta.SetValue("Remark", "inserted")
+}
+
+func (numberFillHandler) UpdateFill(ctx context.Context, meta *gplus.MetaObject) {
+	meta.SetValue("Remark", "updated")
+}
+
+func TestFillNumberConvert(t *testing.T) {
+	gplus.Init(gormDb, gplus.FillHandler(numberFillHandler{}))
+	t.Cleanup(func() {
+		gplus.Init(gormDb)
+	})
+	sessionDb := gormDb.Session(&gorm.Session{DryRun: true})
+	item := &FillItem{}
+	if db := gplus.Insert(item, gplus.Db(sessionDb)); db.Error != nil {
+		t.Fatalf("errors happened when fill number: %v", db.Error)
+	}
+	if item.Version != 1 || item.Score == nil || *item.Score != 1 {
+		t.Errorf("errors happened when fill number, got %+v", item)
+	}
+}
+
+type mismatchFillHandler struct{}
+
+func (mismatchFillHandler) InsertFill(ctx context.Context, meta *gplus.MetaObject) {
+	// 整数不能填充到字符串字段，避免被转换为对应的字符
+	if err := meta.SetValue("Remark", 42); !errors.Is(err, gplus.ErrFillValueType) {
+		panic("set value should return ErrFillValueType")
+	}
+}
+
+func (mismatchFillHandler) UpdateFill(ctx context.Context, meta *gplus.MetaObject) {
+	meta.SetValue("Remark", 42)
+}
+
+func TestFillValueTypeMismatch(t *testing.T) {
+	gplus.Init(gormDb, gplus.FillHandler(mismatchFillHandler{}))
+	t.Cleanup(func() {
+		gplus.Init(gormDb)
+	})
+	sessionDb := gormDb.Session(&gorm.Session{DryRun: true})
+	item := &FillItem{}
+	if db := gplus.Insert(item, gplus.Db(sessionDb)); !errors.Is(db.Error, gplus.ErrFillValueType) {
+		t.Errorf("insert expect error %v, got %v", gplus.ErrFillValueType, db.Error)
+	}
+	if item.Remark != "" {
+		t.Errorf("remark should not be filled, got %q", item.Remark)
+	}
+	if db := gplus.UpdateById(&FillItem{ID: 1}, gplus.Db(sessionDb)); !errors.Is(db.Error, gplus.ErrFillValueType) {
+		t.Errorf("update by id expect error %v, got %v", gplus.ErrFillValueType, db.Error)
+	}
+	query, u := gplus.NewQuery[FillItem]()
+	query.Eq(&u.ID, 1).Set(&u.Version, 2)
+	if db := gplus.Update(query, gplus.Db(sessionDb)); !errors.Is(db.Error, gplus.ErrFillValueType) {
+		t.Errorf("update expect error %v, got %v", gplus.ErrFillValueType, db.Error)
+	}
+}
@@ -0,0 +1,107 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"errors"
+	"github.com/acmestack/gorm-plus/gplus"
+	"gorm.io/gorm"
+	"net/url"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	values := url.Values{}
+	values["q"] = []string{"A.username=afumu", "B.age?=18,20", "B.created_at>2023-01-01"}
+	values["gcond"] = []string{"A|(B)"}
+	values["sort"] = []string{"-age"}
+	query, err := gplus.ParseQuery[User](values)
+	if err != nil {
+		t.Fatalf("errors happened when parse query: %v", err)
+	}
+	var expectSql = "SELECT * FROM `Users` WHERE username = 'afumu' OR ( age IN (18,20) AND created_at > '2023-01-01 00:00:00 +0000 UTC' )  ORDER BY age DESC"
+	checkSubQuerySql(t, expectSql, func(sessionDb *gorm.DB) {
+		gplus.SelectList[User](query, gplus.Db(sessionDb))
+	})
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	values := url.Values{}
+	values["q"] = []string{"A.useranme=afumu", "A.age=abc", "B.score^=1", "created_at<yesterday", "username", "a.b.c=1"}
+	values["gcond"] = []string{"(A|C"}
+	values["select"] = []string{"username,secret"}
+	query, err := gplus.ParseQuery[User](values)
+	if query != nil {
+		t.Fatalf("errors happened when parse query, expect nil query")
+	}
+
+	var errs gplus.QueryParamErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("errors happened when parse query, expect QueryParamErrors, got %v", err)
+	}
+	expects := []gplus.QueryParamError{
+		{Param: "q", Column: "useranme", Op: "=", Err: gplus.ErrUnknownColumn},
+		{Param: "q", Column: "age", Op: "=", Value: "abc", Err: gplus.ErrInvalidValue},
+		{Param: "q", Column: "score", Op: "^=", Value: "1", Err: gplus.ErrInvalidValue},
+		{Param: "q", Column: "created_at", Op: "<", Value: "yesterday", Err: gplus.ErrInvalidValue},
+		{Param: "q", Value: "username", Err: gplus.ErrInvalidCondition},
+		{Param: "q", Op: "=", Value: "a.b.c=1", Err: gplus.ErrInvalidCondition},
+		{Param: "select", Column: "secret", Err: gplus.ErrUnknownColumn},
+		{Param: "gcond", Value: "(A|C", Err: gplus.ErrUnbalancedParentheses},
+	}
+	if len(errs) != len(expects) {
+		t.Fatalf("errors happened when parse query, expect %d errors, got %v", len(expects), err)
+	}
+	for i, expect := range expects {
+		if *errs[i] != expect {
+			t.Errorf("errors happened when parse query, expect %v, got %v", &expect, errs[i])
+		}
+	}
+	if !errors.Is(err, gplus.ErrUnbalancedParentheses) {
+		t.Errorf("errors happened when parse query, expect errors.Is %v", gplus.ErrUnbalancedParentheses)
+	}
+	var paramErr *gplus.QueryParamError
+	if !errors.As(err, &paramErr) || paramErr != errs[0] {
+		t.Errorf("errors happened when parse query, expect errors.As %v, got %v", errs[0], paramErr)
+	}
+}
+
+func TestParseQueryGcondErrors(t *testing.T) {
+	tests := []struct {
+		gcond string
+		value string
+		err   error
+	}{
+		{"name|dept", "dept", gplus.ErrUnknownGroup},
+		{"name)", "name)", gplus.ErrUnbalancedParentheses},
+		{"((name)", "((name)", gplus.ErrUnbalancedParentheses},
+		{"name**name", "name**name", gplus.ErrInvalidCondition},
+		{"name&name", "name&name", gplus.ErrInvalidCondition},
+		{"name!", "name!", gplus.ErrInvalidCondition},
+	}
+	for _, tt := range tests {
+		values := url.Values{}
+		values["q"] = []string{"name.username=afumu"}
+		values["gcond"] = []string{tt.gcond}
+		_, err := gplus.ParseQuery[User](values)
+		var errs gplus.QueryParamErrors
+		if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Value != tt.value || errs[0].Err != tt.err {
+			t.Errorf("errors happened when parse gcond %v, expect %v, got %v", tt.gcond, tt.err, err)
+		}
+	}
+}
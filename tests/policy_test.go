@@ -0,0 +1,86 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"errors"
+	"github.com/acmestack/gorm-plus/gplus"
+	"gorm.io/gorm"
+	"net/url"
+	"testing"
+)
+
+func newUserPolicy() *gplus.QueryPolicy {
+	u := gplus.GetModel[User]()
+	return &gplus.QueryPolicy{
+		FilterColumns: []any{&u.Username, &u.Age},
+		SortColumns:   []any{&u.Age, &u.CreatedAt},
+		SelectColumns: []any{&u.Username, &u.Age},
+		Operators:     map[any][]string{&u.Age: {">", "<", "="}},
+		MaxConditions: 3,
+		MaxDepth:      1,
+	}
+}
+
+func TestQueryWithPolicy(t *testing.T) {
+	values := url.Values{}
+	values["q"] = []string{"username=afumu", "age>18"}
+	values["sort"] = []string{"-age"}
+	values["select"] = []string{"username,age"}
+	query, err := gplus.BuildQueryWithPolicy[User](values, newUserPolicy())
+	if err != nil {
+		t.Fatalf("errors happened when build query with policy: %v", err)
+	}
+	var expectSql = "SELECT `username`,`age` FROM `Users` WHERE username = 'afumu' AND age > 18  ORDER BY age DESC"
+	checkSubQuerySql(t, expectSql, func(sessionDb *gorm.DB) {
+		gplus.SelectList[User](query, gplus.Db(sessionDb))
+	})
+}
+
+func TestQueryWithPolicyErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		values url.Values
+		param  string
+		column string
+		err    error
+	}{
+		{"filter column", url.Values{"q": {"password=123"}}, "q", "password", gplus.ErrColumnNotAllowed},
+		{"operator", url.Values{"q": {"age~=1"}}, "q", "age", gplus.ErrOperatorNotAllowed},
+		{"sort column", url.Values{"sort": {"age,-password"}}, "sort", "password", gplus.ErrColumnNotAllowed},
+		{"sort injection", url.Values{"sort": {"age;drop table users"}}, "sort", "age;drop table users", gplus.ErrColumnNotAllowed},
+		{"select column", url.Values{"select": {"password"}}, "select", "password", gplus.ErrColumnNotAllowed},
+		{"omit column", url.Values{"omit": {"phone"}}, "omit", "phone", gplus.ErrColumnNotAllowed},
+		{"max conditions", url.Values{"q": {"age>1", "age<9", "age=5", "username=a"}}, "q", "", gplus.ErrTooManyConditions},
+		{"max depth", url.Values{"q": {"A.age=1", "B.age=2"}, "gcond": {"((A|B))"}}, "gcond", "", gplus.ErrNestingTooDeep},
+		{"gcond syntax", url.Values{"q": {"A.age=1", "B.age=2"}, "gcond": {"A*(B"}}, "gcond", "", gplus.ErrUnbalancedParentheses},
+		{"gcond group", url.Values{"q": {"A.age=1"}, "gcond": {"X"}}, "gcond", "", gplus.ErrUnknownGroup},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := gplus.BuildQueryWithPolicy[User](tt.values, newUserPolicy())
+			var paramErr *gplus.QueryParamError
+			if query != nil || !errors.As(err, &paramErr) {
+				t.Fatalf("errors happened when build query with policy, expect QueryParamError, got %v", err)
+			}
+			if paramErr.Param != tt.param || paramErr.Column != tt.column || !errors.Is(err, tt.err) {
+				t.Errorf("errors happened when build query with policy, got %v", err)
+			}
+		})
+	}
+}
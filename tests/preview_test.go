@@ -0,0 +1,75 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"github.com/acmestack/gorm-plus/gplus"
+	"reflect"
+	"testing"
+)
+
+func TestToSQL(t *testing.T) {
+	query, u := gplus.NewQuery[User]()
+	query.Eq(&u.Username, "afumu").In(&u.Age, []int{18, 20}).OrderByDesc(&u.ID)
+	sql, args, err := gplus.ToSQL(query)
+	checkPreviewSql(t, "SELECT * FROM `Users` WHERE username = ? AND age IN (?,?)  ORDER BY id DESC", []any{"afumu", 18, 20}, sql, args, err)
+}
+
+func TestToCountSQL(t *testing.T) {
+	query, u := gplus.NewQuery[User]()
+	query.Select(&u.Username).Gt(&u.Age, 18)
+	sql, args, err := gplus.ToCountSQL(query)
+	checkPreviewSql(t, "SELECT count(*) FROM `Users` WHERE age > ?", []any{18}, sql, args, err)
+}
+
+func TestToUpdateSQL(t *testing.T) {
+	query, u := gplus.NewQuery[VersionUser]()
+	query.Eq(&u.ID, 1).Eq(&u.Version, 2).Set(&u.Age, 30)
+	sql, args, err := gplus.ToUpdateSQL(query)
+	checkPreviewSql(t, "UPDATE `version_users` SET `age`=?,`version`=version + 1 WHERE id = ? AND version = ?", []any{30, 1, 2}, sql, args, err)
+}
+
+func TestToDeleteSQL(t *testing.T) {
+	query, u := gplus.NewQuery[User]()
+	query.Eq(&u.ID, 1)
+	sql, args, err := gplus.ToDeleteSQL(query)
+	checkPreviewSql(t, "DELETE FROM `Users` WHERE id = ?", []any{1}, sql, args, err)
+
+	logicQuery, lu := gplus.NewQuery[LogicUser]()
+	logicQuery.Eq(&lu.ID, 1)
+	sql, args, err = gplus.ToDeleteSQL(logicQuery)
+	checkPreviewSql(t, "UPDATE `logic_users` SET `deleted`=? WHERE id = ?  AND deleted = ?", []any{1, 1, 0}, sql, args, err)
+}
+
+func TestExplain(t *testing.T) {
+	query, u := gplus.NewQuery[User]()
+	query.Eq(&u.Username, "afumu")
+	results, err := gplus.Explain(query)
+	if err != nil || len(results) == 0 {
+		t.Errorf("errors happened when explain: %v", err)
+	}
+}
+
+func checkPreviewSql(t *testing.T, expectSql string, expectArgs []any, sql string, args []any, err error) {
+	if err != nil {
+		t.Fatalf("errors happened when preview sql: %v", err)
+	}
+	if sql != expectSql || !reflect.DeepEqual(args, expectArgs) {
+		t.Errorf("errors happened when preview sql expect: %v %v, got %v %v", expectSql, expectArgs, sql, args)
+	}
+}
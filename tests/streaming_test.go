@@ -0,0 +1,105 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"encoding/base64"
+	"errors"
+	"github.com/acmestack/gorm-plus/gplus"
+	"gorm.io/gorm"
+	"testing"
+)
+
+func TestSelectStreamingPage(t *testing.T) {
+	var expectSql = "SELECT * FROM `Users` WHERE id > 10 ORDER BY id ASC LIMIT 21"
+	checkSubQuerySql(t, expectSql, func(sessionDb *gorm.DB) {
+		u := gplus.GetModel[User]()
+		page := gplus.NewStreamingPage[User](&u.ID, int64(10), 20)
+		gplus.SelectStreamingPage(page, nil, gplus.Db(sessionDb), gplus.IgnoreTotal())
+	})
+}
+
+func TestSelectStreamingPagePrev(t *testing.T) {
+	var expectSql = "SELECT * FROM `Users` WHERE id < 10 ORDER BY id DESC LIMIT 21"
+	checkSubQuerySql(t, expectSql, func(sessionDb *gorm.DB) {
+		u := gplus.GetModel[User]()
+		page := gplus.NewStreamingPage[User](&u.ID, int64(10), 20)
+		page.Forward = false
+		gplus.SelectStreamingPage(page, nil, gplus.Db(sessionDb), gplus.IgnoreTotal())
+		if !page.HasNext || page.HasPrev {
+			t.Errorf("errors happened when select prev page, hasNext: %v, hasPrev: %v", page.HasNext, page.HasPrev)
+		}
+	})
+}
+
+func TestSelectStreamingPageSortKeys(t *testing.T) {
+	var expectSql = "SELECT * FROM `Users` WHERE age > 18  ORDER BY created_at DESC,id DESC LIMIT 11"
+	checkSubQuerySql(t, expectSql, func(sessionDb *gorm.DB) {
+		query, u := gplus.NewQuery[User]()
+		query.Gt(&u.Age, 18)
+		page := gplus.NewStreamingPage[User, int64](nil, 0, 10).SortBy(gplus.SortDesc(&u.CreatedAt), gplus.SortDesc(&u.ID))
+		gplus.SelectStreamingPage(page, query, gplus.Db(sessionDb), gplus.IgnoreTotal())
+	})
+}
+
+func TestSelectStreamingPageCursor(t *testing.T) {
+	var expectSql = "SELECT * FROM `Users` WHERE age > 18  AND (created_at, id) < ('2023-01-02 03:04:05 +0000 UTC', 10) ORDER BY created_at DESC,id DESC LIMIT 11"
+	checkSubQuerySql(t, expectSql, func(sessionDb *gorm.DB) {
+		query, u := gplus.NewQuery[User]()
+		query.Gt(&u.Age, 18)
+		page := gplus.NewStreamingPage[User, int64](nil, 0, 10).SortBy(gplus.SortDesc(&u.CreatedAt), gplus.SortDesc(&u.ID))
+		page.Cursor = encodeTestCursor(`["2023-01-02T03:04:05Z",10]`)
+		gplus.SelectStreamingPage(page, query, gplus.Db(sessionDb), gplus.IgnoreTotal())
+	})
+}
+
+func TestSelectStreamingPageCursorMixed(t *testing.T) {
+	var expectSql = "SELECT * FROM `Users` WHERE ((score < 60) OR (score = 60 AND id > 10)) ORDER BY score DESC,id LIMIT 11"
+	checkSubQuerySql(t, expectSql, func(sessionDb *gorm.DB) {
+		u := gplus.GetModel[User]()
+		page := gplus.NewStreamingPage[User, int64](nil, 0, 10).SortBy(gplus.SortDesc(&u.Score), gplus.SortAsc(&u.ID))
+		page.Cursor = encodeTestCursor(`[60,10]`)
+		gplus.SelectStreamingPage(page, nil, gplus.Db(sessionDb), gplus.IgnoreTotal())
+	})
+}
+
+func TestSelectStreamingPageCursorBackward(t *testing.T) {
+	var expectSql = "SELECT * FROM `Users` WHERE ((score > 60) OR (score = 60 AND id < 10)) ORDER BY score,id DESC LIMIT 11"
+	checkSubQuerySql(t, expectSql, func(sessionDb *gorm.DB) {
+		u := gplus.GetModel[User]()
+		page := gplus.NewStreamingPage[User, int64](nil, 0, 10).SortBy(gplus.SortDesc(&u.Score), gplus.SortAsc(&u.ID))
+		page.Cursor = encodeTestCursor(`[60,10]`)
+		page.Forward = false
+		gplus.SelectStreamingPage(page, nil, gplus.Db(sessionDb), gplus.IgnoreTotal())
+	})
+}
+
+func TestSelectStreamingPageInvalidCursor(t *testing.T) {
+	sessionDb := gormDb.Session(&gorm.Session{DryRun: true})
+	u := gplus.GetModel[User]()
+	page := gplus.NewStreamingPage[User, int64](nil, 0, 10).SortBy(gplus.SortDesc(&u.ID))
+	page.Cursor = "not a cursor"
+	_, resultDb := gplus.SelectStreamingPage(page, nil, gplus.Db(sessionDb), gplus.IgnoreTotal())
+	if !errors.Is(resultDb.Error, gplus.ErrInvalidCursor) {
+		t.Errorf("errors happened when select streaming page expect: %v, got %v", gplus.ErrInvalidCursor, resultDb.Error)
+	}
+}
+
+func encodeTestCursor(values string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(values))
+}
@@ -0,0 +1,85 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"github.com/acmestack/gorm-plus/gplus"
+	"gorm.io/gorm"
+	"strings"
+	"testing"
+)
+
+func TestInSub(t *testing.T) {
+	var expectSql = "SELECT * FROM `Users` WHERE age > 18 AND dept IN (SELECT `name` FROM `dept` WHERE status = 1 ) AND score > 60"
+	query, u := gplus.NewQuery[User]()
+	subQuery, d := gplus.NewQuery[Dept]()
+	subQuery.Select(&d.Name).Eq(&d.Status, 1)
+	query.Gt(&u.Age, 18).InSub(&u.Dept, subQuery).Gt(&u.Score, 60)
+	checkSubQuerySql(t, expectSql, func(sessionDb *gorm.DB) {
+		gplus.SelectList[User](query, gplus.Db(sessionDb))
+	})
+}
+
+func TestNotInSub(t *testing.T) {
+	var expectSql = "SELECT * FROM `Users` WHERE dept NOT IN (SELECT `name` FROM `dept` WHERE leader = 'afumu' )"
+	query, u := gplus.NewQuery[User]()
+	subQuery, d := gplus.NewQuery[Dept]()
+	subQuery.Select(&d.Name).Eq(&d.Leader, "afumu")
+	query.NotInSub(&u.Dept, subQuery)
+	checkSubQuerySql(t, expectSql, func(sessionDb *gorm.DB) {
+		gplus.SelectList[User](query, gplus.Db(sessionDb))
+	})
+}
+
+func TestExistsSub(t *testing.T) {
+	var expectSql = "SELECT * FROM `Users` WHERE username = 'afumu' AND EXISTS (SELECT * FROM `dept` WHERE dept.name = Users.dept AND status = 1 )"
+	query, u := gplus.NewQuery[User]()
+	subQuery, d := gplus.NewQuery[Dept]()
+	subQuery.EqColumn(&d.Name, &u.Dept).Eq(&d.Status, 1)
+	query.Eq(&u.Username, "afumu").ExistsSub(subQuery)
+	checkSubQuerySql(t, expectSql, func(sessionDb *gorm.DB) {
+		gplus.SelectList[User](query, gplus.Db(sessionDb))
+	})
+}
+
+func TestNotExistsSub(t *testing.T) {
+	var expectSql = "SELECT * FROM `Users` WHERE NOT EXISTS (SELECT * FROM `dept` WHERE dept.name = Users.dept ) OR age = 20"
+	query, u := gplus.NewQuery[User]()
+	subQuery, d := gplus.NewQuery[Dept]()
+	subQuery.EqColumn(&d.Name, &u.Dept)
+	query.NotExistsSub(subQuery).Or().Eq(&u.Age, 20)
+	checkSubQuerySql(t, expectSql, func(sessionDb *gorm.DB) {
+		gplus.SelectList[User](query, gplus.Db(sessionDb))
+	})
+}
+
+// 子查询在构建外层语句时也会执行一次查询回调，所以记录所有生成的sql，只校验最后生成的外层sql
+func checkSubQuerySql(t *testing.T, expect string, fn func(sessionDb *gorm.DB)) {
+	expect = strings.TrimSpace(expect)
+	sessionDb := gormDb.Session(&gorm.Session{DryRun: true})
+	callback := sessionDb.Callback().Query().After("gorm:query")
+	var sqls []string
+	callback.Register("print_sql", func(db *gorm.DB) {
+		sqls = append(sqls, strings.TrimSpace(buildSql(db)))
+	})
+	fn(sessionDb)
+	callback.Remove("print_sql")
+	if len(sqls) == 0 || sqls[len(sqls)-1] != expect {
+		t.Errorf("errors happened  when select expect: %v, got %v", expect, sqls)
+	}
+}
@@ -0,0 +1,158 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"context"
+	"github.com/acmestack/gorm-plus/gplus"
+	"gorm.io/gorm"
+	"testing"
+)
+
+type TenantUser struct {
+	ID       int64
+	TenantId int64
+	Username string
+	Age      int
+}
+
+func (TenantUser) TableName() string {
+	return "tenant_users"
+}
+
+type tenantKey struct{}
+
+func tenantContext(tenantId int64) context.Context {
+	return context.WithValue(context.Background(), tenantKey{}, tenantId)
+}
+
+// 开启多租户插件，测试结束后恢复默认配置
+func initTenant(t *testing.T, ignoreTables ...string) {
+	gplus.Init(gormDb, gplus.Tenant(&gplus.TenantPlugin{
+		TenantId: func(ctx context.Context) (any, bool) {
+			tenantId, ok := ctx.Value(tenantKey{}).(int64)
+			return tenantId, ok
+		},
+		IgnoreTables: ignoreTables,
+	}))
+	t.Cleanup(func() {
+		gplus.Init(gormDb)
+	})
+}
+
+func TestTenantSelectList(t *testing.T) {
+	initTenant(t)
+	var expectSql = "SELECT * FROM `tenant_users` WHERE username = 'afumu'  AND tenant_id = 1"
+	sessionDb := checkSelectSql(t, expectSql)
+	query, u := gplus.NewQuery[TenantUser]()
+	query.Eq(&u.Username, "afumu")
+	gplus.SelectList[TenantUser](query, gplus.Db(sessionDb.WithContext(tenantContext(1))))
+}
+
+func TestTenantUpdate(t *testing.T) {
+	initTenant(t)
+	var expectSql = "UPDATE `tenant_users` SET `age`=20 WHERE username = 'afumu'  AND tenant_id = 2"
+	sessionDb := checkUpdateSql(t, expectSql)
+	query, u := gplus.NewQuery[TenantUser]()
+	query.Eq(&u.Username, "afumu").Set(&u.Age, 20)
+	gplus.Update(query, gplus.Db(sessionDb.WithContext(tenantContext(2))))
+}
+
+func TestTenantUpdateById(t *testing.T) {
+	initTenant(t)
+	var expectSql = "UPDATE `tenant_users` SET `username`='afumu' WHERE tenant_id = 1 AND `id` = 1"
+	sessionDb := checkUpdateSql(t, expectSql)
+	gplus.UpdateById(&TenantUser{ID: 1, Username: "afumu"}, gplus.Db(sessionDb.WithContext(tenantContext(1))))
+}
+
+func TestTenantDeleteById(t *testing.T) {
+	initTenant(t)
+	var expectSql = "DELETE FROM `tenant_users` WHERE tenant_id = 1 AND `id` = 1"
+	sessionDb := checkDeleteSql(t, expectSql)
+	gplus.DeleteById[TenantUser](1, gplus.Db(sessionDb.WithContext(tenantContext(1))))
+}
+
+func TestTenantInsert(t *testing.T) {
+	initTenant(t)
+	var expectSql = "INSERT INTO `tenant_users` (`tenant_id`,`username`,`age`) VALUES (3,'afumu',18)"
+	sessionDb := checkInsertSql(t, expectSql)
+	user := &TenantUser{Username: "afumu", Age: 18}
+	gplus.Insert(user, gplus.Db(sessionDb.WithContext(tenantContext(3))))
+	if user.TenantId != 3 {
+		t.Errorf("tenant id should be filled when insert, expect: %v, got %v", 3, user.TenantId)
+	}
+}
+
+func TestTenantInsertBatch(t *testing.T) {
+	initTenant(t)
+	var expectSql = "INSERT INTO `tenant_users` (`tenant_id`,`username`,`age`) VALUES (3,'afumu',18),(3,'afumu2',20)"
+	sessionDb := checkInsertSql(t, expectSql)
+	users := []*TenantUser{{Username: "afumu", Age: 18}, {TenantId: 5, Username: "afumu2", Age: 20}}
+	gplus.InsertBatch(users, gplus.Db(sessionDb.WithContext(tenantContext(3))))
+}
+
+func TestTenantIgnoreTable(t *testing.T) {
+	initTenant(t, "tenant_users")
+	var expectSql = "SELECT * FROM `tenant_users` WHERE username = 'afumu'"
+	sessionDb := checkSelectSql(t, expectSql)
+	query, u := gplus.NewQuery[TenantUser]()
+	query.Eq(&u.Username, "afumu")
+	gplus.SelectList[TenantUser](query, gplus.Db(sessionDb))
+}
+
+func TestTenantNotFound(t *testing.T) {
+	initTenant(t)
+	query, u := gplus.NewQuery[TenantUser]()
+	query.Eq(&u.Username, "afumu")
+	sessionDb := gormDb.Session(&gorm.Session{DryRun: true})
+	if _, resultDb := gplus.SelectList[TenantUser](query, gplus.Db(sessionDb)); resultDb.Error != gplus.ErrTenantNotFound {
+		t.Errorf("errors happened when select expect: %v, got %v", gplus.ErrTenantNotFound, resultDb.Error)
+	}
+	if resultDb := gplus.Insert(&TenantUser{Username: "afumu"}, gplus.Db(sessionDb)); resultDb.Error != gplus.ErrTenantNotFound {
+		t.Errorf("errors happened when insert expect: %v, got %v", gplus.ErrTenantNotFound, resultDb.Error)
+	}
+}
+
+func TestTenantOtherTable(t *testing.T) {
+	initTenant(t)
+	var expectSql = "SELECT * FROM `Users` WHERE username = 'afumu'"
+	sessionDb := checkSelectSql(t, expectSql)
+	query, u := gplus.NewQuery[User]()
+	query.Eq(&u.Username, "afumu")
+	gplus.SelectList[User](query, gplus.Db(sessionDb))
+}
+
+func TestTenantJoinTable(t *testing.T) {
+	initTenant(t)
+	var expectSql = "SELECT Users.username FROM `Users` LEFT JOIN tenant_users ON Users.username = tenant_users.username AND tenant_users.tenant_id = 1 WHERE Users.age > 18"
+	sessionDb := checkSelectSql(t, expectSql)
+	query, u := gplus.NewQuery[User]()
+	tu := gplus.GetModel[TenantUser]()
+	query.LeftJoin(tu, &u.Username, &tu.Username).Select(&u.Username).Gt(&u.Age, 18)
+	gplus.SelectList[User](query, gplus.Db(sessionDb.WithContext(tenantContext(1))))
+}
+
+func TestTenantJoinIgnoreTable(t *testing.T) {
+	initTenant(t, "tenant_users")
+	var expectSql = "SELECT Users.username FROM `Users` LEFT JOIN tenant_users ON Users.username = tenant_users.username WHERE Users.age > 18"
+	sessionDb := checkSelectSql(t, expectSql)
+	query, u := gplus.NewQuery[User]()
+	tu := gplus.GetModel[TenantUser]()
+	query.LeftJoin(tu, &u.Username, &tu.Username).Select(&u.Username).Gt(&u.Age, 18)
+	gplus.SelectList[User](query, gplus.Db(sessionDb))
+}
@@ -281,3 +281,43 @@ func TestQueryByGroupNest(t *testing.T) {
 	sessionDb := checkSelectSql(t, expectSql)
 	gplus.SelectList[User](query, gplus.Db(sessionDb))
 }
+
+func TestQueryByGroupMultiCharName(t *testing.T) {
+	values := url.Values{}
+	values["q"] = []string{"name.username=afumu", "name.password=123456", "adult.age>=18"}
+	values["gcond"] = []string{"name * adult"}
+	query := gplus.BuildQuery[User](values)
+	var expectSql = "SELECT * FROM `Users` WHERE username = 'afumu' AND password = '123456' AND age >= 18"
+	sessionDb := checkSelectSql(t, expectSql)
+	gplus.SelectList[User](query, gplus.Db(sessionDb))
+}
+
+func TestQueryByGroupPrecedence(t *testing.T) {
+	values := url.Values{}
+	values["q"] = []string{"A.username=afumu", "B.age=20", "C.score=90"}
+	values["gcond"] = []string{"A|B*C"}
+	query := gplus.BuildQuery[User](values)
+	var expectSql = "SELECT * FROM `Users` WHERE username = 'afumu' OR age = 20 AND score = 90"
+	sessionDb := checkSelectSql(t, expectSql)
+	gplus.SelectList[User](query, gplus.Db(sessionDb))
+}
+
+func TestQueryByGroupNot(t *testing.T) {
+	values := url.Values{}
+	values["q"] = []string{"A.username=afumu", "B.age=20", "B.score=90", "C.dept=开发"}
+	values["gcond"] = []string{"A*!B|!(A|C)"}
+	query := gplus.BuildQuery[User](values)
+	var expectSql = "SELECT * FROM `Users` WHERE username = 'afumu' AND NOT ( age = 20 AND score = 90 ) OR NOT ( username = 'afumu' OR dept = '开发' )"
+	sessionDb := checkSelectSql(t, expectSql)
+	gplus.SelectList[User](query, gplus.Db(sessionDb))
+}
+
+func TestQueryByGroupDeepNest(t *testing.T) {
+	values := url.Values{}
+	values["q"] = []string{"A.username=afumu", "B.age=20", "C.score=90", "D.dept=开发"}
+	values["gcond"] = []string{"((A|(B*(C|D))))"}
+	query := gplus.BuildQuery[User](values)
+	var expectSql = "SELECT * FROM `Users` WHERE ( ( username = 'afumu' OR ( age = 20 AND ( score = 90 OR dept = '开发' ) ) ) )"
+	sessionDb := checkSelectSql(t, expectSql)
+	gplus.SelectList[User](query, gplus.Db(sessionDb))
+}
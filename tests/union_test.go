@@ -0,0 +1,57 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"github.com/acmestack/gorm-plus/gplus"
+	"gorm.io/gorm"
+	"testing"
+)
+
+func TestUnion(t *testing.T) {
+	q1, u := gplus.NewQuery[User]()
+	q1.Select(&u.ID, &u.Username).Gt(&u.Age, 18)
+	q2, _ := gplus.NewQuery[User]()
+	q2.Select(&u.ID, &u.Username).Eq(&u.Dept, "dev")
+
+	query := gplus.Union[User](q1, q2).OrderByDesc(&u.ID).Limit(10).Offset(5)
+	expect := "SELECT * FROM ((SELECT `id`,`username` FROM `Users` WHERE age > 18 ) UNION (SELECT `id`,`username` FROM `Users` WHERE dept = 'dev' )) AS union_query " +
+		"ORDER BY id DESC LIMIT 10 OFFSET 5"
+	checkSubQuerySql(t, expect, func(sessionDb *gorm.DB) {
+		gplus.SelectList(query, gplus.Db(sessionDb))
+	})
+}
+
+func TestUnionAllPage(t *testing.T) {
+	q1, u := gplus.NewQuery[User]()
+	q1.Select(&u.Username, &u.CreatedAt).Eq(&u.Dept, "dev")
+	d := gplus.GetModel[Dept]()
+	q2, _ := gplus.NewQuery[Dept]()
+	q2.Select(&d.Name, "NULL").Eq(&d.Status, 1)
+
+	query := gplus.UnionAll[User](q1, q2).Ne(&u.Username, "admin").OrderByDesc(&u.CreatedAt)
+	sql, args, err := gplus.ToCountSQL(query)
+	checkPreviewSql(t, "SELECT count(*) FROM ((SELECT `username`,`created_at` FROM `Users` WHERE dept = ? ) UNION ALL (SELECT `name`,NULL FROM `dept` WHERE status = ? )) AS union_query WHERE username <> ?",
+		[]any{"dev", 1, "admin"}, sql, args, err)
+
+	expect := "SELECT * FROM ((SELECT `username`,`created_at` FROM `Users` WHERE dept = 'dev' ) UNION ALL (SELECT `name`,NULL FROM `dept` WHERE status = 1 )) AS union_query " +
+		"WHERE username <> 'admin'  ORDER BY created_at DESC LIMIT 10 OFFSET 10"
+	checkRowSql(t, expect, func(sessionDb *gorm.DB) {
+		gplus.SelectPageGeneric[User, User](gplus.NewPage[User](2, 10), query, gplus.Db(sessionDb))
+	})
+}
@@ -0,0 +1,108 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"errors"
+	"github.com/acmestack/gorm-plus/gplus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"testing"
+)
+
+func TestUpsertUpdateAll(t *testing.T) {
+	var expectSql = "INSERT INTO `Users` (`username`,`password`,`age`,`id`) VALUES ('afumu','123456',18,1) ON DUPLICATE KEY UPDATE `username`=VALUES(`username`),`password`=VALUES(`password`),`age`=VALUES(`age`)"
+	user := &User{ID: 1, Username: "afumu", Password: "123456", Age: 18}
+	u := gplus.GetModel[User]()
+	sessionDb := checkInsertSql(t, expectSql)
+	gplus.Upsert(user, gplus.OnConflict(&u.ID), gplus.Db(sessionDb), gplus.Select(&u.ID, &u.Username, &u.Password, &u.Age), gplus.Omit(&u.CreatedAt, &u.UpdatedAt))
+}
+
+func TestUpsertDoUpdates(t *testing.T) {
+	var expectSql = "INSERT INTO `Users` (`username`,`age`,`id`) VALUES ('afumu',18,1) ON DUPLICATE KEY UPDATE `age`=VALUES(`age`)"
+	user := &User{ID: 1, Username: "afumu", Age: 18}
+	u := gplus.GetModel[User]()
+	sessionDb := checkInsertSql(t, expectSql)
+	gplus.Upsert(user, gplus.OnConflict(&u.ID).DoUpdates(&u.Age), gplus.Db(sessionDb), gplus.Select(&u.ID, &u.Username, &u.Age), gplus.Omit(&u.CreatedAt, &u.UpdatedAt))
+}
+
+func TestUpsertDoNothing(t *testing.T) {
+	var expectSql = "INSERT INTO `Users` (`username`,`age`,`id`) VALUES ('afumu',18,1) ON DUPLICATE KEY UPDATE `id`=`id`"
+	user := &User{ID: 1, Username: "afumu", Age: 18}
+	u := gplus.GetModel[User]()
+	sessionDb := checkInsertSql(t, expectSql)
+	gplus.Upsert(user, gplus.OnConflict(&u.ID).DoNothing(), gplus.Db(sessionDb), gplus.Select(&u.ID, &u.Username, &u.Age), gplus.Omit(&u.CreatedAt, &u.UpdatedAt))
+}
+
+func TestUpsertBatch(t *testing.T) {
+	var expectSql = "INSERT INTO `Users` (`username`,`score`,`id`) VALUES ('afumu',100,1),('afumu2',90,2) ON DUPLICATE KEY UPDATE `score`=VALUES(`score`)"
+	users := []*User{{ID: 1, Username: "afumu", Score: 100}, {ID: 2, Username: "afumu2", Score: 90}}
+	u := gplus.GetModel[User]()
+	sessionDb := checkInsertSql(t, expectSql)
+	gplus.UpsertBatch(users, gplus.OnConflict(&u.ID).DoUpdates(&u.Score), gplus.Db(sessionDb), gplus.Select(&u.ID, &u.Username, &u.Score), gplus.Omit(&u.CreatedAt, &u.UpdatedAt))
+}
+
+func TestUpsertUpdateAllWithTenant(t *testing.T) {
+	initTenant(t)
+	var expectSql = "INSERT INTO `tenant_users` (`tenant_id`,`username`,`age`,`id`) VALUES (1,'afumu',18,1) ON DUPLICATE KEY UPDATE `username`=VALUES(`username`),`age`=VALUES(`age`)"
+	user := &TenantUser{ID: 1, Username: "afumu", Age: 18}
+	u := gplus.GetModel[TenantUser]()
+	sessionDb := checkInsertSql(t, expectSql)
+	gplus.Upsert(user, gplus.OnConflict(&u.TenantId, &u.Username), gplus.Db(sessionDb.WithContext(tenantContext(1))))
+}
+
+func TestUpsertWithTenantConflict(t *testing.T) {
+	initTenant(t)
+	u := gplus.GetModel[TenantUser]()
+	sessionDb := gormDb.Session(&gorm.Session{DryRun: true}).WithContext(tenantContext(1))
+	// MySQL 冲突字段不包含租户字段时，冲突更新可能覆盖其他租户的记录
+	for _, conflict := range []*gplus.Conflict{nil, gplus.OnConflict(&u.Username), gplus.OnConflict(&u.Username).DoUpdates(&u.Age)} {
+		user := &TenantUser{ID: 1, Username: "afumu", Age: 18}
+		if err := gplus.Upsert(user, conflict, gplus.Db(sessionDb)).Error; !errors.Is(err, gplus.ErrTenantConflict) {
+			t.Errorf("errors happened when upsert expect %v, got %v", gplus.ErrTenantConflict, err)
+		}
+	}
+	users := []*TenantUser{{ID: 1, Username: "afumu"}}
+	if err := gplus.UpsertBatch(users, gplus.OnConflict(&u.Username), gplus.Db(sessionDb)).Error; !errors.Is(err, gplus.ErrTenantConflict) {
+		t.Errorf("errors happened when upsert batch expect %v, got %v", gplus.ErrTenantConflict, err)
+	}
+	// 冲突时不做处理不会修改其他租户的记录
+	if err := gplus.Upsert(&TenantUser{ID: 1}, gplus.OnConflict(&u.Username).DoNothing(), gplus.Db(sessionDb)).Error; err != nil {
+		t.Errorf("errors happened when upsert do nothing: %v", err)
+	}
+}
+
+func TestUpsertWithTenantWhere(t *testing.T) {
+	initTenant(t)
+	var expectSql = "INSERT INTO `tenant_users` (`tenant_id`,`username`,`age`,`id`) VALUES (1,'afumu',18,1) ON CONFLICT (`username`) DO UPDATE SET `age`=`excluded`.`age` WHERE `tenant_users`.`tenant_id` = 1"
+	user := &TenantUser{ID: 1, Username: "afumu", Age: 18}
+	u := gplus.GetModel[TenantUser]()
+	sessionDb := checkInsertSql(t, expectSql).WithContext(tenantContext(1))
+	// 使用 gorm 默认的 ON CONFLICT 子句，与 Postgres、SQLite 生成的sql一致
+	sessionDb.Dialector = postgresDialector{Dialector: sessionDb.Dialector}
+	sessionDb.ClauseBuilders = map[string]clause.ClauseBuilder{}
+	gplus.Upsert(user, gplus.OnConflict(&u.Username).DoUpdates(&u.Age), gplus.Db(sessionDb))
+}
+
+func TestUpsertUpdateAllWithVersion(t *testing.T) {
+	var expectSql = "INSERT INTO `version_users` (`username`,`age`,`version`,`id`) VALUES ('afumu',18,1,1) ON DUPLICATE KEY UPDATE `username`=VALUES(`username`),`age`=VALUES(`age`)"
+	user := &VersionUser{ID: 1, Username: "afumu", Age: 18, Version: 1}
+	u := gplus.GetModel[VersionUser]()
+	sessionDb := checkInsertSql(t, expectSql)
+	gplus.Upsert(user, gplus.OnConflict(&u.ID), gplus.Db(sessionDb))
+}
@@ -0,0 +1,232 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"github.com/acmestack/gorm-plus/gplus"
+	"math/rand"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestQueryToValues(t *testing.T) {
+	query, u := gplus.NewQuery[User]()
+	query.Eq(&u.Username, "afumu").Ge(&u.Age, 18).In(&u.Score, []int{60, 90}).
+		LikeLeft(&u.Address, "shanghai").IsNull(&u.Dept).
+		OrderByDesc(&u.Age).OrderByAsc(&u.ID).Select(&u.Username, &u.Age)
+	values, err := query.ToValues()
+	expect := url.Values{
+		"q":      {"username=afumu", "age>=18", "score?=60,90", "address~<=shanghai", "dept=null"},
+		"sort":   {"-age,id"},
+		"select": {"username,age"},
+	}
+	encoded, encodeErr := query.Encode()
+	if err != nil || encodeErr != nil || values.Encode() != expect.Encode() || encoded != expect.Encode() {
+		t.Errorf("errors happened when query to values, expect %v, got %v %v %v", expect, values, err, encodeErr)
+	}
+}
+
+func TestQueryToValuesGroup(t *testing.T) {
+	query, u := gplus.NewQuery[User]()
+	query.Eq(&u.Username, "afumu").Or(func(q *gplus.QueryCond[User]) {
+		q.Between(&u.Age, 18, 30).NotLike(&u.Address, "beijing")
+	}).Not(func(q *gplus.QueryCond[User]) {
+		q.Ne(&u.Dept, "开发").Or().IsNotNull(&u.Phone)
+	})
+	values, err := query.ToValues()
+	if err != nil {
+		t.Fatalf("errors happened when query to values: %v", err)
+	}
+	expect := url.Values{
+		"q":     {"g1.username=afumu", "g2.age^=18,30", "g3.address!~=beijing", "g4.dept!=开发", "g5.phone!=null"},
+		"gcond": {"g1|(g2*g3)*!(g4|g5)"},
+	}
+	if values.Encode() != expect.Encode() {
+		t.Errorf("errors happened when query to values, expect %v, got %v", expect, values)
+	}
+}
+
+// ValuesItem 包含各种类型字段的实体，校验url参数还原后参数的类型
+type ValuesItem struct {
+	ID        int64
+	Username  string
+	Address   string
+	Phone     string
+	Dept      string
+	Age       int
+	Score     float64
+	Enabled   bool
+	CreatedAt time.Time
+}
+
+func (ValuesItem) TableName() string {
+	return "values_items"
+}
+
+// 随机生成查询条件，校验 BuildQuery(q.ToValues()) 与原查询条件生成的sql和参数一致
+func TestQueryToValuesRoundTrip(t *testing.T) {
+	for seed := int64(0); seed < 200; seed++ {
+		r := rand.New(rand.NewSource(seed))
+		query, u := gplus.NewQuery[ValuesItem]()
+		randomCondition(r, query, u, 0)
+		if r.Intn(2) == 0 {
+			query.OrderByDesc(&u.Age).OrderByAsc(&u.Username)
+		}
+		if r.Intn(2) == 0 {
+			query.Select(&u.ID, &u.Username)
+		}
+
+		expectSql, expectArgs, err := gplus.ToSQL(query)
+		if err != nil {
+			t.Fatalf("errors happened when preview sql seed %d: %v", seed, err)
+		}
+		values, err := query.ToValues()
+		if err != nil {
+			t.Fatalf("errors happened when query to values seed %d: %v", seed, err)
+		}
+		sql, args, err := gplus.ToSQL(gplus.BuildQuery[ValuesItem](values))
+		if err != nil || sql != expectSql || !reflect.DeepEqual(args, expectArgs) {
+			t.Fatalf("errors happened when round trip seed %d, values: %v, expect: %v %v, got %v %v %v",
+				seed, values, expectSql, expectArgs, sql, args, err)
+		}
+	}
+}
+
+func TestQueryToValuesNotSupported(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(q *gplus.QueryCond[User], u *User)
+	}{
+		{"in sub", func(q *gplus.QueryCond[User], u *User) {
+			sub, _ := gplus.NewQuery[User]()
+			q.Eq(&u.Username, "afumu").InSub(&u.ID, sub.Select(&u.ID))
+		}},
+		{"exists sub", func(q *gplus.QueryCond[User], u *User) {
+			sub, _ := gplus.NewQuery[User]()
+			q.ExistsSub(sub)
+		}},
+		{"column compare", func(q *gplus.QueryCond[User], u *User) { q.EqColumn(&u.Username, &u.Address) }},
+		{"in comma", func(q *gplus.QueryCond[User], u *User) { q.In(&u.Dept, []string{"a,b", "c"}) }},
+		{"eq null", func(q *gplus.QueryCond[User], u *User) { q.Eq(&u.Dept, "NULL") }},
+		{"eq operator", func(q *gplus.QueryCond[User], u *User) { q.Eq(&u.Username, "a<=b") }},
+		{"nested", func(q *gplus.QueryCond[User], u *User) {
+			q.Eq(&u.Age, 18).Or(func(q *gplus.QueryCond[User]) {
+				q.Like(&u.Username, "a~<=b")
+			})
+		}},
+		{"group", func(q *gplus.QueryCond[User], u *User) { q.Eq(&u.Age, 18).Group(&u.Dept) }},
+		{"limit", func(q *gplus.QueryCond[User], u *User) { q.Eq(&u.Age, 18).Limit(10) }},
+		{"function", func(q *gplus.QueryCond[User], u *User) { q.Eq(&u.Age, 18).OrderByDesc(gplus.IfNull(&u.Score, 0)) }},
+		{"pointer value", func(q *gplus.QueryCond[User], u *User) { q.Gt(&u.CreatedAt, &u.CreatedAt) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, u := gplus.NewQuery[User]()
+			tt.build(query, u)
+			values, err := query.ToValues()
+			if values != nil || !errors.Is(err, gplus.ErrValuesNotSupported) {
+				t.Errorf("errors happened when query to values expect %v, got %v %v", gplus.ErrValuesNotSupported, values, err)
+			}
+			if _, err = query.Encode(); !errors.Is(err, gplus.ErrValuesNotSupported) {
+				t.Errorf("errors happened when encode expect %v, got %v", gplus.ErrValuesNotSupported, err)
+			}
+		})
+	}
+}
+
+func randomCondition(r *rand.Rand, q *gplus.QueryCond[ValuesItem], u *ValuesItem, depth int) {
+	for i := 0; i < 1+r.Intn(3); i++ {
+		or := i > 0 && r.Intn(3) == 0
+		switch kind := r.Intn(6); {
+		case kind == 0 && depth < 2:
+			nested := func(nq *gplus.QueryCond[ValuesItem]) {
+				randomCondition(r, nq, u, depth+1)
+			}
+			if or {
+				q.Or(nested)
+			} else {
+				q.And(nested)
+			}
+		case kind == 1 && depth < 2:
+			if or {
+				q.Or()
+			}
+			q.Not(func(nq *gplus.QueryCond[ValuesItem]) {
+				randomCondition(r, nq, u, depth+1)
+			})
+		default:
+			if or {
+				q.Or()
+			}
+			randomLeaf(r, q, u)
+		}
+	}
+}
+
+func randomLeaf(r *rand.Rand, q *gplus.QueryCond[ValuesItem], u *ValuesItem) {
+	word := fmt.Sprintf("w%d", r.Intn(100))
+	number := r.Intn(100)
+	score := float64(number) + 0.25
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 600, time.UTC).Add(time.Duration(number) * time.Hour)
+	switch r.Intn(20) {
+	case 0:
+		q.Eq(&u.Username, word)
+	case 1:
+		q.Ne(&u.Address, word)
+	case 2:
+		q.Gt(&u.Age, number)
+	case 3:
+		q.Ge(&u.Score, score)
+	case 4:
+		q.Lt(&u.Age, number)
+	case 5:
+		q.Le(&u.CreatedAt, createdAt)
+	case 6:
+		q.Like(&u.Username, word)
+	case 7:
+		q.NotLike(&u.Address, word)
+	case 8:
+		q.LikeLeft(&u.Username, word)
+	case 9:
+		q.LikeRight(&u.Phone, word)
+	case 10:
+		q.In(&u.Age, []int{number, number + 1})
+	case 11:
+		q.NotIn(&u.Dept, []string{word, word + "x"})
+	case 12:
+		q.Between(&u.Score, score, score+10)
+	case 13:
+		q.NotBetween(&u.Age, number, number+10)
+	case 14:
+		q.IsNull(&u.Phone)
+	case 15:
+		q.In(&u.Score, []float64{score, score * 2})
+	case 16:
+		q.Between(&u.CreatedAt, createdAt, createdAt.Add(time.Hour))
+	case 17:
+		q.Gt(&u.CreatedAt, createdAt)
+	case 18:
+		q.Eq(&u.Enabled, number%2 == 0)
+	default:
+		q.IsNotNull(&u.Dept)
+	}
+}
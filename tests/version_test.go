@@ -0,0 +1,112 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"errors"
+	"github.com/acmestack/gorm-plus/gplus"
+	"testing"
+)
+
+type VersionUser struct {
+	ID       int64
+	Username string
+	Age      int
+	Version  int `gplus:"version"`
+}
+
+func (VersionUser) TableName() string {
+	return "version_users"
+}
+
+func TestVersionUpdateById(t *testing.T) {
+	var expectSql = "UPDATE `version_users` SET `username`='afumu',`version`=3 WHERE version = 2 AND `id` = 1"
+	sessionDb := checkUpdateSql(t, expectSql)
+	user := &VersionUser{ID: 1, Username: "afumu", Version: 2}
+	gplus.UpdateById(user, gplus.Db(sessionDb))
+	if user.Version != 2 {
+		t.Errorf("version should be restored when no rows affected, expect: %v, got %v", 2, user.Version)
+	}
+}
+
+func TestVersionUpdateZeroById(t *testing.T) {
+	var expectSql = "UPDATE `version_users` SET `username`='afumu',`age`=0,`version`=1 WHERE version = 0 AND `id` = 1"
+	sessionDb := checkUpdateSql(t, expectSql)
+	user := &VersionUser{ID: 1, Username: "afumu"}
+	gplus.UpdateZeroById(user, gplus.Db(sessionDb))
+}
+
+func TestVersionUpdate(t *testing.T) {
+	var expectSql = "UPDATE `version_users` SET `age`=20,`version`=version + 1 WHERE username = 'afumu' AND version = 2"
+	sessionDb := checkUpdateSql(t, expectSql)
+	query, u := gplus.NewQuery[VersionUser]()
+	query.Eq(&u.Username, "afumu").Eq(&u.Version, 2).Set(&u.Age, 20)
+	gplus.Update(query, gplus.Db(sessionDb))
+}
+
+// 重新创建乐观锁测试的记录
+func resetVersionUsers(t *testing.T) []*VersionUser {
+	if err := gormDb.AutoMigrate(&VersionUser{}); err != nil {
+		t.Fatalf("errors happened when migrate: %v", err)
+	}
+	gormDb.Where("1 = 1").Delete(&VersionUser{})
+	users := []*VersionUser{{Username: "afumu", Age: 18, Version: 1}, {Username: "afumu2", Age: 20, Version: 1}}
+	if err := gormDb.Create(&users).Error; err != nil {
+		t.Fatalf("errors happened when create: %v", err)
+	}
+	return users
+}
+
+func TestVersionUpdateBatchById(t *testing.T) {
+	users := resetVersionUsers(t)
+	users[0].Age = 19
+	users[1].Age = 21
+	resultDb := gplus.UpdateBatchById(users)
+	if resultDb.Error != nil || resultDb.RowsAffected != 2 {
+		t.Fatalf("errors happened when update batch by id: %v, rows affected: %v", resultDb.Error, resultDb.RowsAffected)
+	}
+	for _, user := range users {
+		if user.Version != 2 {
+			t.Errorf("errors happened when update batch by id, expect version 2, got %v", user.Version)
+		}
+		saved, _ := gplus.SelectById[VersionUser](user.ID)
+		if saved.Age != user.Age || saved.Version != 2 {
+			t.Errorf("errors happened when update batch by id, expect: %v, got %v", user, saved)
+		}
+	}
+}
+
+func TestVersionUpdateBatchByIdRollback(t *testing.T) {
+	users := resetVersionUsers(t)
+	// 第二条记录已经被其他人修改
+	gormDb.Model(&VersionUser{}).Where("id = ?", users[1].ID).Update("version", 5)
+	users[0].Age = 19
+	users[1].Age = 21
+	resultDb := gplus.UpdateBatchById(users)
+	if !errors.Is(resultDb.Error, gplus.ErrOptimisticLock) {
+		t.Fatalf("errors happened when update batch by id, expect %v, got %v", gplus.ErrOptimisticLock, resultDb.Error)
+	}
+	// 事务回滚后，第一条记录没有被修改，版本号恢复为更新前的版本号
+	if users[0].Version != 1 || users[1].Version != 1 {
+		t.Errorf("errors happened when update batch by id, expect version restored to 1, got %v %v", users[0].Version, users[1].Version)
+	}
+	saved, _ := gplus.SelectById[VersionUser](users[0].ID)
+	if saved.Age != 18 || saved.Version != 1 {
+		t.Errorf("errors happened when update batch by id, expect rollback, got %v", saved)
+	}
+}
@@ -0,0 +1,72 @@
+/*
+ * Licensed to the AcmeStack under one or more contributor license
+ * agreements. See the NOTICE file distributed with this work for
+ * additional information regarding copyright ownership.
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"github.com/acmestack/gorm-plus/gplus"
+	"gorm.io/gorm"
+	"testing"
+)
+
+func TestWindowFunction(t *testing.T) {
+	query, u := gplus.NewQuery[User]()
+	query.Select(
+		&u.Username,
+		gplus.RowNumber().Over(gplus.PartitionBy(&u.Dept).OrderByDesc(&u.Score)).As("rn"),
+		gplus.Rank().Over(gplus.PartitionBy().OrderByDesc(&u.Score)).As("rk"),
+		gplus.DenseRank().Over(gplus.PartitionBy(&u.Dept, &u.Age).OrderByDesc(&u.Score).OrderByAsc(&u.ID)).As("drk"),
+		gplus.Sum(&u.Score).Over(gplus.PartitionBy(&u.Dept).OrderByAsc(&u.CreatedAt)).As("running"),
+		gplus.Count("*").Over(nil).As("total"),
+	)
+	sql, args, err := gplus.ToSQL(query)
+	expect := "SELECT `username`,ROW_NUMBER() OVER (PARTITION BY dept ORDER BY score DESC) AS rn," +
+		"RANK() OVER (ORDER BY score DESC) AS rk," +
+		"DENSE_RANK() OVER (PARTITION BY dept,age ORDER BY score DESC,id ASC) AS drk," +
+		"SUM(score) OVER (PARTITION BY dept ORDER BY created_at ASC) AS running," +
+		"COUNT(*) OVER () AS total FROM `Users`"
+	checkPreviewSql(t, expect, nil, sql, args, err)
+}
+
+func TestWindowLagLead(t *testing.T) {
+	query, u := gplus.NewQuery[User]()
+	window := gplus.PartitionBy(&u.Dept).OrderByAsc(&u.CreatedAt)
+	query.Select(
+		gplus.Lag(&u.Score, 1).Over(window).As("prev_score"),
+		gplus.Lead(&u.Score, 2, 0).Over(window).Alias("next_score"),
+	)
+	sql, args, err := gplus.ToSQL(query)
+	expect := "SELECT LAG(score, 1) OVER (PARTITION BY dept ORDER BY created_at ASC) AS prev_score," +
+		"LEAD(score, 2, ?) OVER (PARTITION BY dept ORDER BY created_at ASC) AS next_score FROM `Users`"
+	checkPreviewSql(t, expect, []any{0}, sql, args, err)
+}
+
+func TestWindowTopNPerGroup(t *testing.T) {
+	inner, u := gplus.NewQuery[User]()
+	inner.Select("*", gplus.RowNumber().Over(gplus.PartitionBy(&u.Dept).OrderByDesc(&u.Score)).As("rn")).
+		Gt(&u.Age, 18)
+
+	query, _ := gplus.NewQuery[User]()
+	query.From(inner, "t").Le("rn", 3).OrderByAsc(&u.Dept, "rn")
+	expect := "SELECT * FROM (SELECT *,ROW_NUMBER() OVER (PARTITION BY dept ORDER BY score DESC) AS rn FROM `Users` WHERE age > 18 ) AS t WHERE rn <= 3  ORDER BY dept ASC,rn ASC"
+	checkSubQuerySql(t, expect, func(sessionDb *gorm.DB) {
+		gplus.SelectList(query, gplus.Db(sessionDb))
+	})
+
+	sql, args, err := gplus.ToCountSQL(query)
+	checkPreviewSql(t, "SELECT count(*) FROM (SELECT *,ROW_NUMBER() OVER (PARTITION BY dept ORDER BY score DESC) AS rn FROM `Users` WHERE age > ? ) AS t WHERE rn <= ?", []any{18, 3}, sql, args, err)
+}